@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ApplyAccessRules adds the predicates of the access rules matching the user and the table of the statement to
+// its WHERE clause. Statements that can't be rewritten safely, e.g. because they aren't SELECT statements or
+// contain comments that could hide the predicates, are rejected. So are statements whose table can't be
+// determined if a rule is scoped to a table, and statements without a user if a rule for users or roles applies
+func ApplyAccessRules(statement string, rules []AccessRule, user *backend.User) (string, error) {
+	table, _ := parseTableName(statement)
+
+	var predicates []string
+	for _, rule := range rules {
+		if rule.Table != "" && table == "" {
+			return "", fmt.Errorf("statement is not allowed by the access rules, its table can't be determined")
+		}
+		if rule.Table != "" && rule.Table != table {
+			continue
+		}
+		if user == nil && (len(rule.Users) > 0 || len(rule.Roles) > 0) {
+			return "", fmt.Errorf("statement is not allowed by the access rules, the user of the query is unknown")
+		}
+		if matchesUser(rule, user) {
+			predicates = append(predicates, "("+rule.Predicate+")")
+		}
+	}
+	if len(predicates) == 0 {
+		return statement, nil
+	}
+
+	if hasComment(statement) {
+		return "", fmt.Errorf("statements with comments are not allowed by the access rules")
+	}
+
+	filtered, ok := addCondition(statement, strings.Join(predicates, " AND "))
+	if !ok {
+		return "", fmt.Errorf("statement is not allowed by the access rules, only SELECT statements with a single WHERE clause are supported")
+	}
+
+	return filtered, nil
+}
+
+// matchesUser reports whether the rule applies to the user. Rules without users and roles apply to everyone
+func matchesUser(rule AccessRule, user *backend.User) bool {
+	if len(rule.Users) == 0 && len(rule.Roles) == 0 {
+		return true
+	}
+
+	for _, u := range rule.Users {
+		if u != "" && (u == user.Login || strings.EqualFold(u, user.Email)) {
+			return true
+		}
+	}
+	for _, r := range rule.Roles {
+		if strings.EqualFold(r, user.Role) {
+			return true
+		}
+	}
+	return false
+}
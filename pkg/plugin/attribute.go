@@ -1,8 +1,8 @@
 package plugin
 
 import (
+	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"time"
 
@@ -12,57 +12,79 @@ import (
 )
 
 type Attribute struct {
-	Name     string
-	Value    *data.Field
-	TsFormat string
+	Name            string
+	Value           *data.Field
+	Datetime        *DatetimeOptions
+	NumberPrecision string
+	TypeOverride    string
+}
+
+// AttributeOptions controls how the values of an attribute are converted
+type AttributeOptions struct {
+	DatetimeFormat    string
+	DatetimeFallbacks []string
+	DatetimeTimezone  string
+	NumberPrecision   string
+	TypeOverride      string
 }
 
 func (c *Attribute) Type() data.FieldType {
 	return c.Value.Type()
 }
 
-func NewAttribute(rowIndex int, name string, value *dynamodb.AttributeValue, datetimeFormat string) (*Attribute, error) {
+func NewAttribute(rowIndex int, name string, value *dynamodb.AttributeValue, options AttributeOptions) (*Attribute, error) {
 	var field *data.Field
 
-	if value.S != nil {
-		// string
-		if datetimeFormat != "" && datetimeFormat != UnixTimestampMiniseconds && datetimeFormat != UnixTimestampSeconds {
-			t, err := time.Parse(datetimeFormat, *value.S)
-			if err != nil {
-				return nil, err
-			}
+	value, err := convertAttributeValue(value, options.TypeOverride)
+	if err != nil {
+		return nil, fmt.Errorf("attribute %s: %w", name, err)
+	}
 
-			field = data.NewField(name, nil, make([]*time.Time, rowIndex+1))
-			field.Set(rowIndex, &t)
+	datetimeFormat := options.DatetimeFormat
+	if options.TypeOverride == AttributeTypeTime && datetimeFormat == "" {
+		datetimeFormat = DatetimeFormatAuto
+	}
 
-		} else {
-			field = data.NewField(name, nil, make([]*string, rowIndex+1))
-			field.Set(rowIndex, value.S)
+	var datetime *DatetimeOptions
+	if datetimeFormat != "" {
+		datetime, err = newDatetimeOptions(datetimeFormat, options.DatetimeFallbacks, options.DatetimeTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
 		}
+	}
 
+	if options.TypeOverride == AttributeTypeJSON && value.NULL == nil {
+		// json
+		v, err := attributeToJson(value, options.NumberPrecision)
+		if err != nil {
+			return nil, err
+		}
+		field = data.NewField(name, nil, make([]*json.RawMessage, rowIndex+1))
+		field.Set(rowIndex, v)
+	} else if datetime != nil && (value.S != nil || value.N != nil) {
+		// datetime
+		t, err := parseDatetime(value, datetime)
+		if err != nil {
+			return nil, err
+		}
+		field = data.NewField(name, nil, make([]*time.Time, rowIndex+1))
+		field.Set(rowIndex, t)
+	} else if value.S != nil {
+		// string
+		field = data.NewField(name, nil, make([]*string, rowIndex+1))
+		field.Set(rowIndex, value.S)
+	} else if value.N != nil && options.NumberPrecision == NumberPrecisionString {
+		// exact decimal string
+		field = data.NewField(name, nil, make([]*string, rowIndex+1))
+		field.Set(rowIndex, value.N)
 	} else if value.N != nil {
-		i, f, err := parseNumber(*value.N)
+		i, f, err := parseNumberWithPrecision(*value.N, options.NumberPrecision)
 		if err != nil {
 			return nil, err
 		} else if i != nil {
 			// int64
-			if datetimeFormat == UnixTimestampSeconds {
-				t := time.Unix(*i, 0)
-				field = data.NewField(name, nil, make([]*time.Time, rowIndex+1))
-				field.Set(rowIndex, &t)
-			} else if datetimeFormat == UnixTimestampMiniseconds {
-				seconds := *i / 1000
-				nanoseconds := (*i % 1000) * 1000000
-				t := time.Unix(seconds, nanoseconds)
-				field = data.NewField(name, nil, make([]*time.Time, rowIndex+1))
-				field.Set(rowIndex, &t)
-			} else if datetimeFormat != "" {
-				return nil, errors.New("invalid datetime format")
-			} else {
-				field = data.NewField(name, nil, make([]*int64, rowIndex+1))
-				field.Set(rowIndex, i)
-			}
-
+			field = data.NewField(name, nil, make([]*int64, rowIndex+1))
+			field.Set(rowIndex, i)
 		} else {
 			// float64
 			field = data.NewField(name, nil, make([]*float64, rowIndex+1))
@@ -71,7 +93,7 @@ func NewAttribute(rowIndex int, name string, value *dynamodb.AttributeValue, dat
 
 	} else if value.B != nil {
 		field = data.NewField(name, nil, make([]*string, rowIndex+1))
-		field.Set(rowIndex, aws.String("[B]"))
+		field.Set(rowIndex, aws.String(base64.StdEncoding.EncodeToString(value.B)))
 	} else if value.BOOL != nil {
 		field = data.NewField(name, nil, make([]*bool, rowIndex+1))
 		field.Set(rowIndex, value.BOOL)
@@ -99,17 +121,21 @@ func NewAttribute(rowIndex int, name string, value *dynamodb.AttributeValue, dat
 		field = data.NewField(name, nil, make([]*json.RawMessage, rowIndex+1))
 		field.Set(rowIndex, v)
 	} else if value.NS != nil {
-		v, err := numberSetToJson(value)
+		v, err := numberSetToJson(value, options.NumberPrecision)
 		if err != nil {
 			return nil, err
 		}
 		field = data.NewField(name, nil, make([]*json.RawMessage, rowIndex+1))
 		field.Set(rowIndex, v)
 	} else if value.BS != nil {
-		field = data.NewField(name, nil, make([]*string, rowIndex+1))
-		field.Set(rowIndex, aws.String("[BS]"))
+		v, err := binarySetToJson(value)
+		if err != nil {
+			return nil, err
+		}
+		field = data.NewField(name, nil, make([]*json.RawMessage, rowIndex+1))
+		field.Set(rowIndex, v)
 	}
-	return &Attribute{Name: name, Value: field, TsFormat: datetimeFormat}, nil
+	return &Attribute{Name: name, Value: field, Datetime: datetime, NumberPrecision: options.NumberPrecision, TypeOverride: options.TypeOverride}, nil
 }
 
 func (c *Attribute) Size() int {
@@ -117,55 +143,55 @@ func (c *Attribute) Size() int {
 }
 
 func (c *Attribute) Append(value *dynamodb.AttributeValue) error {
-	if value.S != nil {
-		if c.TsFormat != "" && c.TsFormat != UnixTimestampMiniseconds && c.TsFormat != UnixTimestampSeconds {
-			if c.Type() != data.FieldTypeNullableTime {
-				return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "S")
-			}
-			t, err := time.Parse(c.TsFormat, *value.S)
-			if err != nil {
-				return err
-			}
-			c.Value.Append(&t)
+	value, err := convertAttributeValue(value, c.TypeOverride)
+	if err != nil {
+		return fmt.Errorf("attribute %s: %w", c.Name, err)
+	}
 
-		} else {
-			if c.Type() != data.FieldTypeNullableString {
-				return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "S")
-			}
-			c.Value.Append(value.S)
+	if c.TypeOverride == AttributeTypeJSON && value.NULL == nil {
+		if c.Type() != data.FieldTypeNullableJSON {
+			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "JSON")
 		}
-
+		v, err := attributeToJson(value, c.NumberPrecision)
+		if err != nil {
+			return err
+		}
+		c.Value.Append(v)
+	} else if c.Datetime != nil && (value.S != nil || value.N != nil) {
+		if c.Type() != data.FieldTypeNullableTime {
+			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), dataType(value))
+		}
+		t, err := parseDatetime(value, c.Datetime)
+		if err != nil {
+			return err
+		}
+		c.Value.Append(t)
+	} else if value.S != nil {
+		if c.Type() != data.FieldTypeNullableString {
+			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "S")
+		}
+		c.Value.Append(value.S)
+	} else if value.N != nil && c.NumberPrecision == NumberPrecisionString {
+		if c.Type() != data.FieldTypeNullableString {
+			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "N")
+		}
+		c.Value.Append(value.N)
 	} else if value.N != nil {
-		i, f, err := parseNumber(*value.N)
+		i, f, err := parseNumberWithPrecision(*value.N, c.NumberPrecision)
 		if err != nil {
 			return err
 		} else if i != nil {
 			// int64
-			if c.TsFormat == UnixTimestampSeconds {
-				if c.Type() != data.FieldTypeNullableTime {
-					return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "N")
-				}
-				t := time.Unix(*i, 0)
-				c.Value.Append(&t)
-			} else if c.TsFormat == UnixTimestampMiniseconds {
-				if c.Type() != data.FieldTypeNullableTime {
-					return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "N")
+			if c.Type() == data.FieldTypeNullableInt64 {
+				c.Value.Append(i)
+			} else if c.Type() == data.FieldTypeNullableFloat64 {
+				f, err := int64ToFloat64(*i, c.NumberPrecision)
+				if err != nil {
+					return err
 				}
-
-				seconds := *i / 1000
-				nanoseconds := (*i % 1000) * 1000000
-				t := time.Unix(seconds, nanoseconds)
-				c.Value.Append(&t)
-			} else if c.TsFormat != "" {
-				return errors.New("invalid datetime format")
+				c.Value.Append(f)
 			} else {
-				if c.Type() == data.FieldTypeNullableInt64 {
-					c.Value.Append(i)
-				} else if c.Type() == data.FieldTypeNullableFloat64 {
-					c.Value.Append(aws.Float64(float64(*i)))
-				} else {
-					return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "N")
-				}
+				return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "N")
 			}
 
 		} else {
@@ -179,7 +205,11 @@ func (c *Attribute) Append(value *dynamodb.AttributeValue) error {
 				for i := 0; i < c.Value.Len(); i++ {
 					cv, ok := c.Value.ConcreteAt(i)
 					if ok {
-						float64Values[i] = aws.Float64(float64(cv.(int64)))
+						f, err := int64ToFloat64(cv.(int64), c.NumberPrecision)
+						if err != nil {
+							return err
+						}
+						float64Values[i] = f
 					}
 				}
 
@@ -193,7 +223,7 @@ func (c *Attribute) Append(value *dynamodb.AttributeValue) error {
 		if c.Type() != data.FieldTypeNullableString {
 			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "B")
 		}
-		c.Value.Append(aws.String("[B]"))
+		c.Value.Append(aws.String(base64.StdEncoding.EncodeToString(value.B)))
 	} else if value.BOOL != nil {
 		if c.Type() != data.FieldTypeNullableBool {
 			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "BOOL")
@@ -232,17 +262,28 @@ func (c *Attribute) Append(value *dynamodb.AttributeValue) error {
 		if c.Type() != data.FieldTypeNullableJSON {
 			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "NS")
 		}
-		v, err := numberSetToJson(value)
+		v, err := numberSetToJson(value, c.NumberPrecision)
 		if err != nil {
 			return err
 		}
 		c.Value.Append(v)
 	} else if value.BS != nil {
-		if c.Type() != data.FieldTypeNullableString {
+		if c.Type() != data.FieldTypeNullableJSON {
 			return fmt.Errorf("field %s should have type %s, but got %s", c.Name, c.Type().ItemTypeString(), "BS")
 		}
-		c.Value.Append(aws.String("[BS]"))
+		v, err := binarySetToJson(value)
+		if err != nil {
+			return err
+		}
+		c.Value.Append(v)
 	}
 
 	return nil
 }
+
+func dataType(value *dynamodb.AttributeValue) string {
+	if value.N != nil {
+		return "N"
+	}
+	return "S"
+}
@@ -0,0 +1,297 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// AuditRecord describes the execution of one query
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	User          string    `json:"user"`
+	OrgID         int64     `json:"orgId"`
+	DatasourceUID string    `json:"datasourceUid"`
+	DashboardUID  string    `json:"dashboardUid,omitempty"`
+	PanelID       string    `json:"panelId,omitempty"`
+	RefID         string    `json:"refId"`
+	// Statement sent to DynamoDB with the values of sensitive attributes replaced by "?"
+	Statement        string   `json:"statement"`
+	Table            string   `json:"table"`
+	Index            string   `json:"index,omitempty"`
+	Regions          []string `json:"regions,omitempty"`
+	ConsumedCapacity float64  `json:"consumedCapacity"`
+	RowCount         int      `json:"rowCount"`
+	DurationMs       int64    `json:"durationMs"`
+	// AWS error code, or status of the response if the query failed before reaching DynamoDB
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+func newAuditRecord(req *backend.QueryDataRequest, query backend.DataQuery) *AuditRecord {
+	record := &AuditRecord{
+		Time:         time.Now().UTC(),
+		OrgID:        req.PluginContext.OrgID,
+		DashboardUID: req.GetHTTPHeader("X-Dashboard-Uid"),
+		PanelID:      req.GetHTTPHeader("X-Panel-Id"),
+		RefID:        query.RefID,
+	}
+	if req.PluginContext.User != nil {
+		record.User = req.PluginContext.User.Login
+	}
+	if req.PluginContext.DataSourceInstanceSettings != nil {
+		record.DatasourceUID = req.PluginContext.DataSourceInstanceSettings.UID
+	}
+	return record
+}
+
+// finish sets the duration and the error code of the response
+func (r *AuditRecord) finish(response backend.DataResponse, duration time.Duration) {
+	r.DurationMs = duration.Milliseconds()
+	if response.Error != nil && r.ErrorCode == "" {
+		r.ErrorCode = response.Status.String()
+	}
+}
+
+// awsErrorCode returns the code of an AWS error, e.g. "ResourceNotFoundException"
+func awsErrorCode(err error) string {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return awsErr.Code()
+	}
+	return ""
+}
+
+// auditLog writes audit records to the plugin logger and, if configured, to a JSON lines file
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (a *auditLog) write(settings ExtraPluginSettings, record *AuditRecord) {
+	args := []interface{}{
+		"user", record.User,
+		"orgId", record.OrgID,
+		"datasourceUid", record.DatasourceUID,
+		"dashboardUid", record.DashboardUID,
+		"panelId", record.PanelID,
+		"refId", record.RefID,
+		"statement", record.Statement,
+		"table", record.Table,
+		"index", record.Index,
+		"consumedCapacity", record.ConsumedCapacity,
+		"rowCount", record.RowCount,
+		"durationMs", record.DurationMs,
+		"errorCode", record.ErrorCode,
+	}
+	switch settings.AuditLogLevel {
+	case "debug":
+		backend.Logger.Debug("Query audit", args...)
+	case "warn":
+		backend.Logger.Warn("Query audit", args...)
+	default:
+		backend.Logger.Info("Query audit", args...)
+	}
+
+	if settings.AuditLogFile == "" {
+		return
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		backend.Logger.Error("failed to marshal audit record", "error", err.Error())
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		a.file, err = os.OpenFile(settings.AuditLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			backend.Logger.Error("failed to open audit log file", "error", err.Error())
+			return
+		}
+	}
+
+	_, err = a.file.Write(append(line, '\n'))
+	if err != nil {
+		backend.Logger.Error("failed to write audit record", "error", err.Error())
+	}
+}
+
+func (a *auditLog) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file != nil {
+		_ = a.file.Close()
+		a.file = nil
+	}
+}
+
+// Keywords after which a value no longer belongs to the attribute before them
+var redactionResetKeywords = []string{"SELECT", "FROM", "WHERE", "AND", "OR", "ORDER", "BY", "ASC", "DESC", "SET", "REMOVE", "VALUE", "INSERT", "INTO", "UPDATE", "DELETE"}
+
+// Keywords between an attribute and its values, e.g. email IS NOT NULL or email IN ['a']
+var redactionOperatorKeywords = []string{"IN", "IS", "NOT", "MISSING", "NULL", "TRUE", "FALSE"}
+
+// RedactStatement replaces the string and number literals compared with the sensitive attributes by "?", e.g.
+// email = 'bob@example.com', 'a' = email, email IN ['a', 'b'], begins_with(email, 'b') or ts BETWEEN 1 AND 2.
+// Literals whose attribute can't be determined are redacted as well, and "*" redacts all literals
+func RedactStatement(statement string, sensitiveAttributes []string) string {
+	if len(sensitiveAttributes) == 0 {
+		return statement
+	}
+	redactAll := slices.Contains(sensitiveAttributes, "*")
+
+	tokens := statementTokens(statement)
+	var b strings.Builder
+	attribute := ""
+	between := false
+	for i, token := range tokens {
+		switch token.kind {
+		case tokenIdentifier:
+			keyword := strings.ToUpper(token.text)
+			switch {
+			case i > 0 && tokens[i-1].text == ".":
+				// Nested path of the attribute
+			case keyword == "BETWEEN":
+				between = true
+			case keyword == "AND" && between:
+				between = false
+			case slices.Contains(redactionResetKeywords, keyword):
+				attribute = ""
+			case slices.Contains(redactionOperatorKeywords, keyword):
+				// The values after them still belong to the attribute
+			case tokenAt(tokens, nextToken(tokens, i)).text == "(":
+				// Function name
+			default:
+				attribute = unquoteIdentifier(token.text)
+			}
+		case tokenString, tokenNumber:
+			next := nextToken(tokens, i)
+			if token.kind == tokenString && tokenAt(tokens, next).text == ":" {
+				// Key of a tuple, e.g. {'email': 'bob@example.com'}
+				attribute = unquoteLiteral(token.text)
+				break
+			}
+			if token.kind == tokenNumber && i > 1 && tokens[i-1].text == "[" && (tokens[i-2].kind == tokenIdentifier || tokens[i-2].text == "]") {
+				// List index of a path, e.g. events[0]
+				break
+			}
+
+			name := attribute
+			if isComparison(tokenAt(tokens, next).text) {
+				// Reversed comparison, e.g. 'a' = email
+				if after := tokenAt(tokens, nextToken(tokens, next)); after.kind == tokenIdentifier {
+					name = unquoteIdentifier(after.text)
+				}
+			}
+			if redactAll || name == "" || slices.Contains(sensitiveAttributes, name) {
+				b.WriteByte('?')
+				continue
+			}
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+const (
+	tokenSpace = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type statementToken struct {
+	kind int
+	text string
+}
+
+// statementTokens splits the statement into identifiers, quoted identifiers, literals, symbols and whitespace,
+// whose concatenation is the statement
+func statementTokens(statement string) []statementToken {
+	var tokens []statementToken
+	for i := 0; i < len(statement); {
+		start := i
+		kind := tokenSymbol
+		c := statement[i]
+		switch {
+		case c == '\'' || c == '"':
+			for i++; i < len(statement); i++ {
+				if statement[i] == c {
+					if i+1 < len(statement) && statement[i+1] == c {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			i = min(i+1, len(statement))
+			kind = tokenString
+			if c == '"' {
+				kind = tokenIdentifier
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			for i < len(statement) && strings.IndexByte(" \t\r\n", statement[i]) >= 0 {
+				i++
+			}
+			kind = tokenSpace
+		case c >= '0' && c <= '9':
+			for i < len(statement) && (statement[i] >= '0' && statement[i] <= '9' || statement[i] == '.' || statement[i] == 'e' || statement[i] == 'E') {
+				i++
+			}
+			kind = tokenNumber
+		case !isWordBoundary(statement, i):
+			for i < len(statement) && !isWordBoundary(statement, i) {
+				i++
+			}
+			kind = tokenIdentifier
+		default:
+			i++
+			// Two character operators
+			if i < len(statement) && slices.Contains([]string{"<=", ">=", "<>", "!="}, statement[start:i+1]) {
+				i++
+			}
+		}
+		tokens = append(tokens, statementToken{kind: kind, text: statement[start:i]})
+	}
+	return tokens
+}
+
+// nextToken returns the position of the first token after i that isn't whitespace, or len(tokens) if none
+func nextToken(tokens []statementToken, i int) int {
+	for i++; i < len(tokens); i++ {
+		if tokens[i].kind != tokenSpace {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+func tokenAt(tokens []statementToken, i int) statementToken {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return statementToken{}
+}
+
+func isComparison(op string) bool {
+	return slices.Contains([]string{"=", "<>", "!=", "<", "<=", ">", ">="}, op)
+}
+
+func unquoteLiteral(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Maximum size of a decompressed binary value, which protects against compression bombs
+const maxDecompressedSize = 10 << 20
+
+// decodeBinary decodes a binary value with the given encoding. Text encodings return an S value,
+// compressed encodings are decompressed and parsed as JSON.
+func decodeBinary(b []byte, encoding string) (*dynamodb.AttributeValue, error) {
+	switch encoding {
+	case "", BinaryEncodingBase64:
+		return &dynamodb.AttributeValue{S: aws.String(base64.StdEncoding.EncodeToString(b))}, nil
+	case BinaryEncodingHex:
+		return &dynamodb.AttributeValue{S: aws.String(hex.EncodeToString(b))}, nil
+	case BinaryEncodingText:
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("invalid UTF-8 text")
+		}
+		return &dynamodb.AttributeValue{S: aws.String(string(b))}, nil
+	case BinaryEncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return decompressedJsonToAttributeValue(r)
+	case BinaryEncodingZlib:
+		r, err := zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return decompressedJsonToAttributeValue(r)
+	}
+
+	return nil, fmt.Errorf("invalid binary encoding %s", encoding)
+}
+
+func decompressedJsonToAttributeValue(r io.Reader) (*dynamodb.AttributeValue, error) {
+	limited := &io.LimitedReader{R: r, N: maxDecompressedSize + 1}
+	decoder := json.NewDecoder(limited)
+	// Keep numbers as their text so that they don't lose precision
+	decoder.UseNumber()
+
+	var v interface{}
+	err := decoder.Decode(&v)
+	if limited.N <= 0 {
+		return nil, fmt.Errorf("decompressed value exceeds %d bytes", maxDecompressedSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jsonToAttributeValue(v), nil
+}
+
+// jsonToAttributeValue converts a value decoded with json.Decoder.UseNumber into an attribute value
+func jsonToAttributeValue(v interface{}) *dynamodb.AttributeValue {
+	switch t := v.(type) {
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(t)}
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(t.String())}
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(t)}
+	case []interface{}:
+		l := make([]*dynamodb.AttributeValue, len(t))
+		for i, e := range t {
+			l[i] = jsonToAttributeValue(e)
+		}
+		return &dynamodb.AttributeValue{L: l}
+	case map[string]interface{}:
+		m := make(map[string]*dynamodb.AttributeValue, len(t))
+		for k, e := range t {
+			m[k] = jsonToAttributeValue(e)
+		}
+		return &dynamodb.AttributeValue{M: m}
+	}
+	return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+}
+
+// DecodeBinaryAttributes replaces the B and BS values of the given attributes with their decoded values
+func DecodeBinaryAttributes(items []map[string]*dynamodb.AttributeValue, binaryAttributes []BinaryAttribute) error {
+	for _, ba := range binaryAttributes {
+		for _, item := range items {
+			value, ok := item[ba.Name]
+			if !ok {
+				continue
+			}
+
+			if value.B != nil {
+				v, err := decodeBinary(value.B, ba.Encoding)
+				if err != nil {
+					return fmt.Errorf("decode binary attribute %s: %w", ba.Name, err)
+				}
+				item[ba.Name] = v
+			} else if value.BS != nil {
+				l := make([]*dynamodb.AttributeValue, len(value.BS))
+				for i, b := range value.BS {
+					v, err := decodeBinary(b, ba.Encoding)
+					if err != nil {
+						return fmt.Errorf("decode binary attribute %s: %w", ba.Name, err)
+					}
+					l[i] = v
+				}
+				item[ba.Name] = &dynamodb.AttributeValue{L: l}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+)
+
+// clientCache keeps the HTTP client and the DynamoDB clients of a datasource instance, so that
+// connections are reused across queries instead of being opened for every request
+type clientCache struct {
+	mu         sync.Mutex
+	httpClient *http.Client
+	clients    map[clientKey]cachedClient
+}
+
+// clientKey identifies the effective settings of a client
+type clientKey struct {
+	region        string
+	assumeRoleARN string
+}
+
+type cachedClient struct {
+	session *session.Session
+	client  *dynamodb.DynamoDB
+}
+
+// getHTTPClient returns the HTTP client of the datasource, creating it on first use
+func (c *clientCache) getHTTPClient(ctx context.Context, settings *backend.DataSourceInstanceSettings) (*http.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.httpClient != nil {
+		return c.httpClient, nil
+	}
+
+	httpClientOptions, err := settings.HTTPClientOptions(ctx)
+	if err != nil {
+		backend.Logger.Error("failed to create http client options", err.Error())
+		return nil, err
+	}
+
+	httpClient, err := httpclient.NewProvider().New(httpClientOptions)
+	if err != nil {
+		backend.Logger.Error("failed to create http client", err.Error())
+		return nil, err
+	}
+
+	c.httpClient = httpClient
+	return httpClient, nil
+}
+
+// getClient returns the cached client of the settings, or creates one if the session changed, e.g. because
+// the session cache renewed expired credentials
+func (c *clientCache) getClient(key clientKey, session *session.Session) *dynamodb.DynamoDB {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[key]; ok && cached.session == session {
+		return cached.client
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[clientKey]cachedClient)
+	}
+	client := dynamodb.New(session)
+	c.clients[key] = cachedClient{session: session, client: client}
+	return client
+}
+
+// close drops the clients and closes the idle connections of the HTTP client
+func (c *clientCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+	c.httpClient = nil
+	c.clients = nil
+}
+
+// clientKey returns the effective settings of a query, the region and assume role ARN of the datasource
+// unless the query overrides them
+func (d *Datasource) clientKey(region string, assumeRoleARN string) clientKey {
+	key := clientKey{region: d.Settings.Region, assumeRoleARN: d.Settings.AssumeRoleARN}
+	if region != "" {
+		key.region = region
+	}
+	if assumeRoleARN != "" {
+		key.assumeRoleARN = assumeRoleARN
+	}
+	return key
+}
+
+// checkOverrides returns an error if the region or assume role ARN of a query isn't allowed by the settings
+func (d *Datasource) checkOverrides(region string, assumeRoleARN string) error {
+	if region != "" && region != d.Settings.Region && !slices.Contains(d.ExtraSettings.AllowedRegions, region) {
+		return fmt.Errorf("region %s is not allowed", region)
+	}
+	if assumeRoleARN != "" && assumeRoleARN != d.Settings.AssumeRoleARN && !slices.Contains(d.ExtraSettings.AllowedAssumeRoleARNs, assumeRoleARN) {
+		return fmt.Errorf("assume role ARN %s is not allowed", assumeRoleARN)
+	}
+	return nil
+}
+
+func (d *Datasource) getSession(ctx context.Context, settings *backend.DataSourceInstanceSettings, key clientKey) (*session.Session, error) {
+	httpClient, err := d.clients.getHTTPClient(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	awsSettings := d.Settings
+	awsSettings.Region = key.region
+	awsSettings.AssumeRoleARN = key.assumeRoleARN
+
+	return d.sessionCache.GetSessionWithAuthSettings(awsds.GetSessionConfig{
+		Settings:      awsSettings,
+		HTTPClient:    httpClient,
+		UserAgentName: aws.String("DynamoDB"),
+	}, d.authSettings)
+}
+
+func (d *Datasource) getDynamoDBClient(ctx context.Context, settings *backend.DataSourceInstanceSettings, key clientKey) (*dynamodb.DynamoDB, error) {
+	session, err := d.getSession(ctx, settings, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.clients.getClient(key, session), nil
+}
@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Alias of DatetimeFormatAuto in key templates
+const compositeFormatISO = "iso"
+
+var placeholderRegex = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]*))?\}`)
+
+type compositePart struct {
+	name   string
+	format string
+}
+
+// parseKeyTemplate converts a key template like "ORDER#{createdAt:iso}#{orderId}" into an anchored
+// regular expression with one capture group per placeholder
+func parseKeyTemplate(template string) (*regexp.Regexp, []compositePart, error) {
+	var parts []compositePart
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	last := 0
+	for _, m := range placeholderRegex.FindAllStringSubmatchIndex(template, -1) {
+		expr.WriteString(regexp.QuoteMeta(template[last:m[0]]))
+		part := compositePart{name: strings.TrimSpace(template[m[2]:m[3]])}
+		if m[4] >= 0 {
+			part.format = strings.TrimSpace(template[m[4]:m[5]])
+		}
+		parts = append(parts, part)
+		expr.WriteString("(.*?)")
+		last = m[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(template[last:]))
+	expr.WriteString("$")
+
+	if len(parts) == 0 {
+		return nil, nil, fmt.Errorf("key template %s has no placeholders", template)
+	}
+
+	r, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid key template %s: %w", template, err)
+	}
+
+	return r, parts, nil
+}
+
+// compositeParts returns the regular expression of the composite attribute and its parts. The parts of
+// a pattern are its named capture groups, other groups are ignored
+func compositeParts(ca CompositeAttribute) (*regexp.Regexp, []compositePart, error) {
+	if ca.Template != "" {
+		return parseKeyTemplate(ca.Template)
+	}
+
+	r, err := regexp.Compile(ca.Pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pattern of composite attribute %s: %w", ca.Name, err)
+	}
+
+	parts := make([]compositePart, len(r.SubexpNames()))
+	for i, name := range r.SubexpNames() {
+		parts[i] = compositePart{name: name}
+	}
+
+	return r, parts[1:], nil
+}
+
+// ParseCompositeAttributes splits the S values of each composite attribute into its parts and stores
+// them in the item as S attributes. Values not matching the template or pattern are skipped
+func ParseCompositeAttributes(items []map[string]*dynamodb.AttributeValue, compositeAttributes []CompositeAttribute) error {
+	for _, ca := range compositeAttributes {
+		r, parts, err := compositeParts(ca)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			value, ok := item[ca.Name]
+			if !ok || value.S == nil {
+				continue
+			}
+
+			m := r.FindStringSubmatch(*value.S)
+			if m == nil {
+				continue
+			}
+
+			for i, part := range parts {
+				if part.name != "" {
+					item[part.name] = &dynamodb.AttributeValue{S: aws.String(m[i+1])}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// compositeFormats returns the type or datetime format of each typed part of the key templates
+func compositeFormats(compositeAttributes []CompositeAttribute) (types map[string]string, datetimeFormats map[string]string) {
+	types = make(map[string]string)
+	datetimeFormats = make(map[string]string)
+	for _, ca := range compositeAttributes {
+		if ca.Template == "" {
+			continue
+		}
+
+		_, parts, err := parseKeyTemplate(ca.Template)
+		if err != nil {
+			continue
+		}
+
+		for _, part := range parts {
+			switch part.format {
+			case "":
+			case AttributeTypeString, AttributeTypeNumber, AttributeTypeInt, AttributeTypeFloat, AttributeTypeBool, AttributeTypeJSON:
+				types[part.name] = part.format
+			case compositeFormatISO, AttributeTypeTime:
+				datetimeFormats[part.name] = DatetimeFormatAuto
+			default:
+				datetimeFormats[part.name] = part.format
+			}
+		}
+	}
+
+	return types, datetimeFormats
+}
+
+// isCompositePart reports whether the attribute is a part of a composite attribute of the query
+func isCompositePart(qm QueryModel, name string) bool {
+	for _, ca := range qm.CompositeAttributes {
+		_, parts, err := compositeParts(ca)
+		if err != nil {
+			continue
+		}
+		for _, part := range parts {
+			if part.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// convertAttributeValue converts a DynamoDB value into the given attribute type.
+// Time values are only validated here, they are parsed with the datetime format of the attribute.
+func convertAttributeValue(value *dynamodb.AttributeValue, attributeType string) (*dynamodb.AttributeValue, error) {
+	if value.NULL != nil {
+		return value, nil
+	}
+
+	switch attributeType {
+	case "":
+		return value, nil
+	case AttributeTypeString:
+		if value.S != nil {
+			return value, nil
+		} else if value.N != nil {
+			return &dynamodb.AttributeValue{S: value.N}, nil
+		} else if value.BOOL != nil {
+			return &dynamodb.AttributeValue{S: aws.String(strconv.FormatBool(*value.BOOL))}, nil
+		} else if value.M != nil || value.L != nil || value.SS != nil || value.NS != nil {
+			v, err := toJson(value)
+			if err != nil {
+				return nil, err
+			}
+			return &dynamodb.AttributeValue{S: aws.String(string(*v))}, nil
+		}
+	case AttributeTypeNumber, AttributeTypeFloat:
+		if value.N != nil {
+			return value, nil
+		} else if value.S != nil {
+			if _, _, err := parseNumber(*value.S); err != nil {
+				return nil, err
+			}
+			return &dynamodb.AttributeValue{N: value.S}, nil
+		} else if value.BOOL != nil {
+			return &dynamodb.AttributeValue{N: aws.String(boolToNumber(*value.BOOL))}, nil
+		}
+	case AttributeTypeInt:
+		var n string
+		if value.N != nil {
+			n = *value.N
+		} else if value.S != nil {
+			n = *value.S
+		} else if value.BOOL != nil {
+			n = boolToNumber(*value.BOOL)
+		} else {
+			break
+		}
+		if _, err := strconv.ParseInt(n, 10, 64); err != nil {
+			return nil, fmt.Errorf("%s is not an integer", n)
+		}
+		return &dynamodb.AttributeValue{N: aws.String(n)}, nil
+	case AttributeTypeBool:
+		if value.BOOL != nil {
+			return value, nil
+		} else if value.S != nil || value.N != nil {
+			s := aws.StringValue(value.S)
+			if value.N != nil {
+				s = *value.N
+			}
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, err
+			}
+			return &dynamodb.AttributeValue{BOOL: aws.Bool(b)}, nil
+		}
+	case AttributeTypeTime:
+		if value.S != nil || value.N != nil {
+			return value, nil
+		}
+	case AttributeTypeJSON:
+		// Values are rendered as JSON when the field is built
+		if value.S != nil && !json.Valid([]byte(*value.S)) {
+			return nil, fmt.Errorf("%s is not valid JSON", *value.S)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("invalid attribute type %s", attributeType)
+	}
+
+	return nil, fmt.Errorf("can't convert value %v to %s", value, attributeType)
+}
+
+// attributeToJson renders any value as JSON. S values are JSON documents and are kept as they are
+func attributeToJson(value *dynamodb.AttributeValue, precision string) (*json.RawMessage, error) {
+	var raw json.RawMessage
+	switch {
+	case value.S != nil:
+		raw = json.RawMessage(*value.S)
+	case value.N != nil:
+		raw = json.RawMessage(*value.N)
+	case value.BOOL != nil:
+		raw = json.RawMessage(strconv.FormatBool(*value.BOOL))
+	case value.B != nil:
+		b, err := json.Marshal(value.B)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	case value.M != nil:
+		return mapToJson(value)
+	case value.L != nil:
+		return listToJson(value)
+	case value.SS != nil:
+		return stringSetToJson(value)
+	case value.NS != nil:
+		return numberSetToJson(value, precision)
+	case value.BS != nil:
+		return binarySetToJson(value)
+	default:
+		raw = json.RawMessage("null")
+	}
+	return &raw, nil
+}
+
+func boolToNumber(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func toJson(value *dynamodb.AttributeValue) (*json.RawMessage, error) {
+	if value.M != nil {
+		return mapToJson(value)
+	} else if value.L != nil {
+		return listToJson(value)
+	} else if value.SS != nil {
+		return stringSetToJson(value)
+	}
+	return numberSetToJson(value, NumberPrecisionAuto)
+}
+
+// inferAttributeTypes returns the S attributes whose non-null values are all numbers
+func inferAttributeTypes(items []map[string]*dynamodb.AttributeValue) map[string]string {
+	numeric := make(map[string]bool)
+	for _, item := range items {
+		for name, value := range item {
+			if value.NULL != nil {
+				continue
+			}
+			isNumber := false
+			if value.S != nil {
+				_, _, err := parseNumber(*value.S)
+				isNumber = err == nil
+			}
+			if n, ok := numeric[name]; !ok || n {
+				numeric[name] = isNumber
+			}
+		}
+	}
+
+	types := make(map[string]string)
+	for name, n := range numeric {
+		if n {
+			types[name] = AttributeTypeNumber
+		}
+	}
+	return types
+}
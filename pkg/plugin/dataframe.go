@@ -1,26 +1,155 @@
 package plugin
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
-func QueryResultToDataFrame(dataFrameName string, output *dynamodb.ExecuteStatementOutput, datetimeAttributes map[string]string) (*data.Frame, error) {
+// DataFrameOptions controls how attributes are converted into data frame fields
+type DataFrameOptions struct {
+	// Datetime format by attribute name
+	DatetimeAttributes map[string]string
+	// Fallback datetime formats by attribute name
+	DatetimeFallbacks map[string][]string
+	// Time zone of timestamps without offset by attribute name
+	DatetimeTimezones map[string]string
+	// Default precision mode of number attributes
+	NumberPrecision string
+	// Precision mode by attribute name, overrides NumberPrecision
+	NumberPrecisionAttributes map[string]string
+	// Type override by attribute name
+	AttributeTypes map[string]string
+	// Promote S attributes to numbers when all their values are numeric
+	InferTypes bool
+}
+
+// mergeDatetimeAttributes returns the default datetime attributes of the table followed by the attributes
+// of the query, so that query attributes override table defaults, which override global defaults
+func mergeDatetimeAttributes(defaults []DefaultDatetimeAttribute, table string, attributes []DatetimeAttribute) []DatetimeAttribute {
+	var merged []DatetimeAttribute
+	for _, d := range defaults {
+		if d.Table == "" {
+			merged = append(merged, d.DatetimeAttribute)
+		}
+	}
+	for _, d := range defaults {
+		if d.Table != "" && d.Table == table {
+			merged = append(merged, d.DatetimeAttribute)
+		}
+	}
+
+	return append(merged, attributes...)
+}
+
+// newDataFrameOptions collects the attribute options of the query model
+func newDataFrameOptions(qm QueryModel) DataFrameOptions {
+	datetimeAttributes := make(map[string]string)
+	datetimeFallbacks := make(map[string][]string)
+	datetimeTimezones := make(map[string]string)
+	// Later attributes replace all options of earlier attributes with the same name
+	for _, k := range qm.DatetimeAttributes {
+		datetimeAttributes[k.Name] = k.Format
+		datetimeFallbacks[k.Name] = k.Fallbacks
+		datetimeTimezones[k.Name] = k.Timezone
+	}
+	for _, k := range qm.ExtractedAttributes {
+		if k.Format != "" {
+			datetimeAttributes[k.Name] = k.Format
+			delete(datetimeFallbacks, k.Name)
+			delete(datetimeTimezones, k.Name)
+		}
+	}
+	compositeTypes, compositeDatetimeFormats := compositeFormats(qm.CompositeAttributes)
+	for name, format := range compositeDatetimeFormats {
+		datetimeAttributes[name] = format
+		delete(datetimeFallbacks, name)
+		delete(datetimeTimezones, name)
+	}
+
+	numberPrecisionAttributes := make(map[string]string)
+	for _, k := range qm.NumberPrecisionAttributes {
+		numberPrecisionAttributes[k.Name] = k.Precision
+	}
+
+	attributeTypes := make(map[string]string)
+	for name, t := range compositeTypes {
+		attributeTypes[name] = t
+	}
+	for _, k := range qm.ExtractedAttributes {
+		if k.Type != "" {
+			attributeTypes[k.Name] = k.Type
+		}
+	}
+	for _, k := range qm.TypedAttributes {
+		attributeTypes[k.Name] = k.Type
+	}
+
+	return DataFrameOptions{
+		DatetimeAttributes:        datetimeAttributes,
+		DatetimeFallbacks:         datetimeFallbacks,
+		DatetimeTimezones:         datetimeTimezones,
+		NumberPrecision:           qm.NumberPrecision,
+		NumberPrecisionAttributes: numberPrecisionAttributes,
+		AttributeTypes:            attributeTypes,
+		InferTypes:                qm.InferTypes,
+	}
+}
+
+func (o DataFrameOptions) attributeOptions(name string) AttributeOptions {
+	options := AttributeOptions{
+		NumberPrecision: o.NumberPrecision,
+	}
+
+	if df, ok := o.DatetimeAttributes[name]; ok {
+		options.DatetimeFormat = df
+		options.DatetimeFallbacks = o.DatetimeFallbacks[name]
+		options.DatetimeTimezone = o.DatetimeTimezones[name]
+	}
+
+	if np, ok := o.NumberPrecisionAttributes[name]; ok {
+		options.NumberPrecision = np
+	}
+
+	if t, ok := o.AttributeTypes[name]; ok {
+		options.TypeOverride = t
+		if t == AttributeTypeFloat {
+			options.NumberPrecision = NumberPrecisionFloat
+		} else if t == AttributeTypeInt {
+			options.NumberPrecision = NumberPrecisionAuto
+		}
+	}
+
+	return options
+}
+
+func QueryResultToDataFrame(dataFrameName string, output *dynamodb.ExecuteStatementOutput, options DataFrameOptions) (*data.Frame, error) {
+	if options.InferTypes {
+		attributeTypes := inferAttributeTypes(output.Items)
+		for name, t := range options.AttributeTypes {
+			attributeTypes[name] = t
+		}
+		for name := range options.DatetimeAttributes {
+			if _, ok := options.AttributeTypes[name]; !ok {
+				delete(attributeTypes, name)
+			}
+		}
+		options.AttributeTypes = attributeTypes
+	}
+
 	attributes := make(map[string]*Attribute)
 	for rowIndex, row := range output.Items {
 		for name, value := range row {
-			datetimeFormat := ""
-			if df, ok := datetimeAttributes[name]; ok {
-				datetimeFormat = df
-			}
-
 			if a, ok := attributes[name]; ok {
 				err := a.Append(value)
 				if err != nil {
 					return nil, err
 				}
 			} else {
-				newAttribute, err := NewAttribute(rowIndex, name, value, datetimeFormat)
+				newAttribute, err := NewAttribute(rowIndex, name, value, options.attributeOptions(name))
 				if err != nil {
 					return nil, err
 				}
@@ -46,3 +175,78 @@ func QueryResultToDataFrame(dataFrameName string, output *dynamodb.ExecuteStatem
 
 	return frame, nil
 }
+
+// SetPrimaryTimeField makes the time field the first field of the frame, sorts the rows ascending by it and
+// marks the frame as a time series. Rows without time are removed.
+func SetPrimaryTimeField(frame *data.Frame, name string) error {
+	timeField, timeFieldIndex := frame.FieldByName(name)
+	if timeField == nil {
+		return nil
+	}
+
+	if timeField.Type() != data.FieldTypeNullableTime {
+		return fmt.Errorf("primary time attribute %s should be a datetime attribute", name)
+	}
+
+	var rows []int
+	for i := 0; i < timeField.Len(); i++ {
+		if _, ok := timeField.ConcreteAt(i); ok {
+			rows = append(rows, i)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		ti, _ := timeField.ConcreteAt(rows[i])
+		tj, _ := timeField.ConcreteAt(rows[j])
+		return ti.(time.Time).Before(tj.(time.Time))
+	})
+
+	fields := []*data.Field{reorderField(timeField, rows)}
+	for i, f := range frame.Fields {
+		if i != timeFieldIndex {
+			fields = append(fields, reorderField(f, rows))
+		}
+	}
+	frame.Fields = fields
+
+	frameType := data.FrameTypeTimeSeriesWide
+	for _, f := range fields[1:] {
+		if !f.Type().Numeric() {
+			frameType = data.FrameTypeTimeSeriesLong
+		}
+	}
+	if frameType == data.FrameTypeTimeSeriesWide && hasDuplicates(fields[0]) {
+		frameType = data.FrameTypeTimeSeriesLong
+	}
+
+	if frame.Meta == nil {
+		frame.Meta = &data.FrameMeta{}
+	}
+	frame.Meta.Type = frameType
+	frame.Meta.TypeVersion = data.FrameTypeVersion{0, 1}
+
+	return nil
+}
+
+// reorderField returns a copy of the field with the values at the given indexes
+func reorderField(field *data.Field, rows []int) *data.Field {
+	f := data.NewFieldFromFieldType(field.Type(), len(rows))
+	f.Name = field.Name
+	f.Labels = field.Labels
+	f.Config = field.Config
+	for i, row := range rows {
+		f.Set(i, field.CopyAt(row))
+	}
+	return f
+}
+
+func hasDuplicates(timeField *data.Field) bool {
+	for i := 1; i < timeField.Len(); i++ {
+		previous, _ := timeField.ConcreteAt(i - 1)
+		current, _ := timeField.ConcreteAt(i)
+		if previous.(time.Time).Equal(current.(time.Time)) {
+			return true
+		}
+	}
+	return false
+}
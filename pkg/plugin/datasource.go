@@ -3,14 +3,17 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/grafana/grafana-aws-sdk/pkg/awsds"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
-	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 // Make sure Datasource implements required interfaces. This is important to do
@@ -21,6 +24,7 @@ import (
 var (
 	_ backend.QueryDataHandler      = (*Datasource)(nil)
 	_ backend.CheckHealthHandler    = (*Datasource)(nil)
+	_ backend.CallResourceHandler   = (*Datasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*Datasource)(nil)
 )
 
@@ -33,56 +37,108 @@ func NewDatasource(ctx context.Context, settings backend.DataSourceInstanceSetti
 		return nil, err
 	}
 
+	extraSettings := &ExtraPluginSettings{}
+	if len(settings.JSONData) > 0 {
+		extraSettings, err = loadExtraPluginSettings(settings)
+		if err != nil {
+			backend.Logger.Error("failed to load extra settings", err.Error())
+			return nil, err
+		}
+	}
+
 	authSettings := awsds.ReadAuthSettings(ctx)
 	sessionCache := awsds.NewSessionCache()
 
 	return &Datasource{
-		Settings:     dsSetting,
-		authSettings: *authSettings,
-		sessionCache: sessionCache,
+		Settings:      dsSetting,
+		ExtraSettings: *extraSettings,
+		authSettings:  *authSettings,
+		sessionCache:  sessionCache,
 	}, nil
 }
 
 // Datasource is an example datasource which can respond to data queries, reports
 // its health and has streaming skills.
 type Datasource struct {
-	Settings     awsds.AWSDatasourceSettings
-	sessionCache *awsds.SessionCache
-	authSettings awsds.AuthSettings
+	Settings      awsds.AWSDatasourceSettings
+	ExtraSettings ExtraPluginSettings
+	sessionCache  *awsds.SessionCache
+	authSettings  awsds.AuthSettings
+	tables        tableDescriptionCache
+	clients       clientCache
+	audit         auditLog
 }
 
 // Dispose here tells plugin SDK that plugin wants to clean up resources when a new instance
 // created. As soon as datasource settings change detected by SDK old datasource instance will
 // be disposed and a new one will be created using NewSampleDatasource factory function.
 func (d *Datasource) Dispose() {
-	// Clean up datasource instance resources.
+	d.clients.close()
+	d.audit.close()
+}
+
+// applyDefaults merges the default datetime attributes of the settings into the query model
+func (d *Datasource) applyDefaults(qm *QueryModel) {
+	if len(d.ExtraSettings.DatetimeAttributes) > 0 {
+		table, _ := parseTableName(qm.QueryText)
+		qm.DatetimeAttributes = mergeDatetimeAttributes(d.ExtraSettings.DatetimeAttributes, table, qm.DatetimeAttributes)
+	}
 }
 
-func (d *Datasource) getDynamoDBClient(ctx context.Context, settings *backend.DataSourceInstanceSettings) (*dynamodb.DynamoDB, error) {
-	httpClientProvider := httpclient.NewProvider()
-	httpClientOptions, err := settings.HTTPClientOptions(ctx)
+// planStatement returns whether the SELECT statement is executed as a Query or a Scan
+func (d *Datasource) planStatement(ctx context.Context, dynamoDBClient *dynamodb.DynamoDB, key clientKey, statement string) (StatementPlan, error) {
+	if isWriteStatement(statement) {
+		return StatementPlan{}, fmt.Errorf("only SELECT statements are executed as a Query or a Scan")
+	}
+
+	table, _ := parseTableName(statement)
+	if table == "" {
+		return StatementPlan{}, fmt.Errorf("table of statement not found, the statement should have a single FROM clause")
+	}
+
+	description, err := d.tables.describeTable(ctx, dynamoDBClient, key, table)
 	if err != nil {
-		backend.Logger.Error("failed to create http client options", err.Error())
-		return nil, err
+		return StatementPlan{}, err
 	}
 
-	httpClient, err := httpClientProvider.New(httpClientOptions)
+	plan, err := AnalyzeStatement(statement, description)
 	if err != nil {
-		backend.Logger.Error("failed to create http client", err.Error())
-		return nil, err
+		return StatementPlan{}, err
 	}
 
-	session, err := d.sessionCache.GetSessionWithAuthSettings(awsds.GetSessionConfig{
-		Settings:      d.Settings,
-		HTTPClient:    httpClient,
-		UserAgentName: aws.String("DynamoDB"),
-	}, d.authSettings)
+	backend.Logger.Debug("Statement plan", "table", plan.Table, "index", plan.Index, "operation", plan.Operation)
+	return plan, nil
+}
 
+// checkScanGuard returns an error if the scan guard blocks the statement, or warning notices if it warns about it.
+// In warn mode, statements that can't be analyzed, e.g. because DescribeTable is denied, only get a warning
+func (d *Datasource) checkScanGuard(ctx context.Context, settings *backend.DataSourceInstanceSettings, key clientKey, statement string) ([]data.Notice, error) {
+	// Write statements address items by their primary key and never scan
+	if d.ExtraSettings.ScanGuard == ScanGuardOff || isWriteStatement(statement) {
+		return nil, nil
+	}
+
+	dynamoDBClient, err := d.getDynamoDBClient(ctx, settings, key)
+	var plan StatementPlan
+	if err == nil {
+		plan, err = d.planStatement(ctx, dynamoDBClient, key, statement)
+	}
 	if err != nil {
+		if d.ExtraSettings.ScanGuard == ScanGuardWarn {
+			return []data.Notice{{Severity: data.NoticeSeverityWarning, Text: fmt.Sprintf("scan guard can't check the statement: %s", err.Error())}}, nil
+		}
 		return nil, err
 	}
 
-	return dynamodb.New(session), nil
+	if plan.Operation == OperationScan && !isScanAllowed(d.ExtraSettings, plan.Table) {
+		message := fmt.Sprintf("statement scans the whole table %s, add a condition on the partition key %s to the WHERE clause", plan.Table, plan.PartitionKey)
+		if d.ExtraSettings.ScanGuard == ScanGuardWarn {
+			return []data.Notice{{Severity: data.NoticeSeverityWarning, Text: message}}, nil
+		}
+		return nil, errors.New(message)
+	}
+
+	return nil, nil
 }
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -91,20 +147,62 @@ func (d *Datasource) getDynamoDBClient(ctx context.Context, settings *backend.Da
 // contains Frames ([]*Frame).
 func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	response := backend.NewQueryDataResponse()
-	dynamoDBClient, err := d.getDynamoDBClient(ctx, req.PluginContext.DataSourceInstanceSettings)
-	if err != nil {
-		return nil, err
+	maxConcurrentQueries := d.ExtraSettings.MaxConcurrentQueries
+	if maxConcurrentQueries <= 0 {
+		maxConcurrentQueries = defaultMaxConcurrentQueries
 	}
+	// Validated when the settings are loaded
+	timeout, _ := time.ParseDuration(d.ExtraSettings.QueryTimeout)
 
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentQueries)
 	for _, q := range req.Queries {
-		res := d.query(ctx, dynamoDBClient, q)
-		response.Responses[q.RefID] = res
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(q backend.DataQuery) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			record := newAuditRecord(req, q)
+			start := time.Now()
+			res := d.queryWithTimeout(ctx, req.PluginContext, q, timeout, record)
+			if d.ExtraSettings.AuditLog {
+				record.finish(res, time.Since(start))
+				d.audit.write(d.ExtraSettings, record)
+			}
+
+			mu.Lock()
+			response.Responses[q.RefID] = res
+			mu.Unlock()
+		}(q)
 	}
+	wg.Wait()
 
 	return response, nil
 }
 
-func (d *Datasource) query(ctx context.Context, dynamoDBClient *dynamodb.DynamoDB, query backend.DataQuery) backend.DataResponse {
+// queryWithTimeout executes the query with its own context, so that a query timing out doesn't cancel the others
+func (d *Datasource) queryWithTimeout(ctx context.Context, pluginContext backend.PluginContext, query backend.DataQuery, timeout time.Duration, record *AuditRecord) backend.DataResponse {
+	if timeout <= 0 {
+		return d.query(ctx, pluginContext, query, record)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := d.query(queryCtx, pluginContext, query, record)
+	if res.Error != nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return backend.ErrDataResponse(backend.StatusTimeout, fmt.Sprintf("query timed out after %s", timeout))
+	}
+	return res
+}
+
+// query executes a query and fills the audit record with the statement and its results
+func (d *Datasource) query(ctx context.Context, pluginContext backend.PluginContext, query backend.DataQuery, record *AuditRecord) backend.DataResponse {
+	settings := pluginContext.DataSourceInstanceSettings
 	var response backend.DataResponse
 
 	var qm QueryModel
@@ -116,69 +214,154 @@ func (d *Datasource) query(ctx context.Context, dynamoDBClient *dynamodb.DynamoD
 
 	backend.Logger.Debug("Query model", qm)
 
+	record.Statement = RedactStatement(qm.QueryText, d.ExtraSettings.AuditSensitiveAttributes)
+	record.Table, record.Index = parseTableName(qm.QueryText)
+
+	regions := qm.Regions
+	if len(regions) == 0 {
+		regions = []string{qm.Region}
+	}
+	if qm.RegionOutput != "" && qm.RegionOutput != RegionOutputColumn && qm.RegionOutput != RegionOutputSeries {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid region output %s", qm.RegionOutput))
+	}
+	if len(regions) > 1 && isExtractedAttribute(qm, RegionAttribute) {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("attribute %s is reserved for the region when querying several regions", RegionAttribute))
+	}
+	for _, region := range regions {
+		err = d.checkOverrides(region, qm.AssumeRoleARN)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusForbidden, err.Error())
+		}
+	}
+
+	qm.QueryText, err = ApplyAccessRules(qm.QueryText, d.ExtraSettings.AccessRules, pluginContext.User)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusForbidden, err.Error())
+	}
+
+	d.applyDefaults(&qm)
+	dataFrameOptions := newDataFrameOptions(qm)
+
+	statement, timeFilter, err := timeFilterStatement(qm, dataFrameOptions, query.TimeRange)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	// Checks the statement that is executed, including the time filter
+	notices, err := d.checkScanGuard(ctx, settings, d.clientKey(regions[0], qm.AssumeRoleARN), statement)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
 	input := &dynamodb.ExecuteStatementInput{
-		Statement: aws.String(qm.QueryText),
+		Statement: aws.String(statement),
 	}
 
 	if qm.Limit > 0 {
 		input.Limit = aws.Int64(qm.Limit)
 	}
 
-	output, err := dynamoDBClient.ExecuteStatementWithContext(ctx, input)
+	if d.ExtraSettings.AuditLog {
+		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
+	}
+
+	record.Statement = RedactStatement(statement, d.ExtraSettings.AuditSensitiveAttributes)
+	record.Table, record.Index = parseTableName(statement)
+	if len(regions) > 1 {
+		record.Regions = regions
+	}
 
+	items, consumedCapacity, regionNotices, err := d.executeInRegions(ctx, settings, regions, qm.AssumeRoleARN, input)
+	record.ConsumedCapacity = consumedCapacity
 	if err != nil {
-		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("executes statement: %v", err.Error()))
+		record.ErrorCode = awsErrorCode(err)
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+	record.RowCount = len(items)
+	notices = append(notices, regionNotices...)
+	output := &dynamodb.ExecuteStatementOutput{Items: items}
+
+	if len(qm.BinaryAttributes) > 0 {
+		err = DecodeBinaryAttributes(output.Items, qm.BinaryAttributes)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
 	}
 
-	datetimeAttributes := make(map[string]string)
-	for _, k := range qm.DatetimeAttributes {
-		datetimeAttributes[k.Name] = k.Format
+	if len(qm.ExtractedAttributes) > 0 {
+		err = ExtractAttributes(output.Items, qm.ExtractedAttributes)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("extract attributes: %v", err.Error()))
+		}
 	}
 
-	frame, err := QueryResultToDataFrame(query.RefID, output, datetimeAttributes)
-	if err != nil {
-		response.Error = err
-		return response
+	if len(qm.CompositeAttributes) > 0 {
+		err = ParseCompositeAttributes(output.Items, qm.CompositeAttributes)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
 	}
 
-	response.Frames = append(response.Frames, frame)
-	return response
-}
+	if qm.UnnestAttribute != "" {
+		output.Items = UnnestItems(output.Items, qm.UnnestAttribute)
+	}
 
-// CheckHealth handles health checks sent from Grafana to the plugin.
-// The main use case for these health checks is the test button on the
-// datasource configuration page which allows users to verify that
-// a datasource is working as expected.
-func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
-	backend.Logger.Debug("Checking health")
-	res := &backend.CheckHealthResult{}
+	if qm.FlattenDepth > 0 {
+		output.Items = FlattenItems(output.Items, qm.FlattenDepth, qm.FlattenListMode)
+	}
 
-	client, err := d.getDynamoDBClient(ctx, req.PluginContext.DataSourceInstanceSettings)
-	if err != nil {
-		res.Status = backend.HealthStatusError
-		res.Message = err.Error()
-		return res, nil
+	if timeFilter != nil {
+		output.Items, err = FilterItemsByTime(output.Items, qm.TimeFilterAttribute, timeFilter, query.TimeRange)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
 	}
 
-	extraSettings, err := loadExtraPluginSettings(*req.PluginContext.DataSourceInstanceSettings)
-	if err != nil {
-		res.Status = backend.HealthStatusError
-		res.Message = err.Error()
-		return res, err
+	entities := []Entity{{Name: query.RefID, Items: output.Items}}
+	if qm.EntityDiscriminator.Attribute != "" {
+		entities, err = SplitItemsByEntity(output.Items, qm.EntityDiscriminator)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
 	}
 
-	_, err = client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
-		TableName: aws.String(extraSettings.ConnectionTestTable),
-	})
+	if len(regions) > 1 && qm.RegionOutput == RegionOutputSeries {
+		entities = SplitEntitiesByRegion(entities, regions, qm.EntityDiscriminator.Attribute != "")
+	}
 
-	if err != nil {
-		res.Status = backend.HealthStatusError
-		res.Message = err.Error()
-		return res, nil
+	var frames data.Frames
+	for _, entity := range entities {
+		frame, err := QueryResultToDataFrame(entity.Name, &dynamodb.ExecuteStatementOutput{Items: entity.Items}, dataFrameOptions)
+		if err != nil {
+			response.Error = err
+			return response
+		}
+
+		if entity.Labels != nil {
+			for _, field := range frame.Fields {
+				if field.Type() != data.FieldTypeNullableTime {
+					field.Labels = entity.Labels
+				}
+			}
+		}
+
+		entityFrames, err := outputFrames(frame, qm)
+		if err != nil {
+			return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+		}
+		frames = append(frames, entityFrames...)
 	}
 
-	return &backend.CheckHealthResult{
-		Status:  backend.HealthStatusOk,
-		Message: "Successfully connects to DynamoDB",
-	}, nil
+	if len(notices) > 0 {
+		// Notices need a frame, e.g. when the regions that didn't fail returned no items in series output
+		if len(frames) == 0 {
+			frames = data.Frames{data.NewFrame(query.RefID)}
+		}
+		for _, frame := range frames {
+			frame.AppendNotices(notices...)
+		}
+	}
+
+	response.Frames = append(response.Frames, frames...)
+	return response
 }
@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Layouts tried by DatetimeFormatAuto, in order
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// DatetimeOptions controls how the values of a datetime attribute are parsed
+type DatetimeOptions struct {
+	Format string
+	// Formats tried in order when the value can't be parsed with Format
+	Fallbacks []string
+	// Location of timestamps without time zone. UTC if nil
+	Location *time.Location
+}
+
+func newDatetimeOptions(format string, fallbacks []string, timezone string) (*DatetimeOptions, error) {
+	location := time.UTC
+	if timezone != "" {
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+		location = l
+	}
+
+	return &DatetimeOptions{
+		Format:    format,
+		Fallbacks: fallbacks,
+		Location:  location,
+	}, nil
+}
+
+// parseDatetime parses an S or N value with the datetime format of the attribute, then with the fallbacks
+func parseDatetime(value *dynamodb.AttributeValue, options *DatetimeOptions) (*time.Time, error) {
+	var s string
+	if value.S != nil {
+		s = *value.S
+	} else if value.N != nil {
+		s = *value.N
+	} else {
+		return nil, errors.New("datetime value should be S or N")
+	}
+
+	t, err := parseDatetimeWithFormat(s, options.Format, options.Location)
+	if err == nil {
+		return t, nil
+	}
+
+	for _, f := range options.Fallbacks {
+		t, fallbackErr := parseDatetimeWithFormat(s, f, options.Location)
+		if fallbackErr == nil {
+			return t, nil
+		}
+	}
+
+	return nil, err
+}
+
+func parseDatetimeWithFormat(s string, format string, location *time.Location) (*time.Time, error) {
+	switch format {
+	case UnixTimestampSeconds, DatetimeFormatUnixSeconds:
+		return parseEpoch(s, time.Second)
+	case UnixTimestampMiniseconds, DatetimeFormatUnixMilliseconds:
+		return parseEpoch(s, time.Millisecond)
+	case DatetimeFormatUnixMicroseconds:
+		return parseEpoch(s, time.Microsecond)
+	case DatetimeFormatUnixNanoseconds:
+		return parseEpoch(s, time.Nanosecond)
+	case DatetimeFormatYYYYMMDD:
+		return parseLayout(s, "20060102", location)
+	case DatetimeFormatAuto:
+		if _, ok := new(big.Rat).SetString(s); ok && !strings.Contains(s, "/") {
+			return parseEpoch(s, guessEpochUnit(s))
+		}
+		for _, layout := range isoLayouts {
+			if t, err := parseLayout(s, layout, location); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("can't detect datetime format of %s", s)
+	}
+
+	return parseLayout(s, format, location)
+}
+
+func parseLayout(s string, layout string, location *time.Location) (*time.Time, error) {
+	t, err := time.ParseInLocation(layout, s, location)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseEpoch parses an integer or decimal Unix timestamp in the given unit
+func parseEpoch(s string, unit time.Duration) (*time.Time, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid Unix timestamp %s", s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(int64(unit)))
+	nanoseconds := new(big.Int).Quo(r.Num(), r.Denom())
+	if !nanoseconds.IsInt64() {
+		return nil, fmt.Errorf("unix timestamp %s out of range", s)
+	}
+
+	t := time.Unix(0, nanoseconds.Int64())
+	return &t, nil
+}
+
+// guessEpochUnit guesses the unit of a Unix timestamp from the number of digits of its integer part
+func guessEpochUnit(s string) time.Duration {
+	digits := len(strings.TrimLeft(strings.SplitN(s, ".", 2)[0], "-+"))
+	if digits <= 11 {
+		return time.Second
+	} else if digits <= 14 {
+		return time.Millisecond
+	} else if digits <= 17 {
+		return time.Microsecond
+	}
+	return time.Nanosecond
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Entity groups the items of one entity type of a single-table design
+type Entity struct {
+	Name  string
+	Items []map[string]*dynamodb.AttributeValue
+	// Labels added to the fields of the frame
+	Labels data.Labels
+}
+
+// SplitItemsByEntity groups the items by the entity type read from the discriminator attribute, in the order
+// the entity types first appear. Items without entity type are grouped under an empty name.
+func SplitItemsByEntity(items []map[string]*dynamodb.AttributeValue, discriminator EntityDiscriminator) ([]Entity, error) {
+	var pattern *regexp.Regexp
+	if discriminator.Pattern != "" {
+		p, err := regexp.Compile(discriminator.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entity pattern: %w", err)
+		}
+		pattern = p
+	}
+
+	var entities []Entity
+	indexes := make(map[string]int)
+	for _, item := range items {
+		name := ""
+		if value, ok := item[discriminator.Attribute]; ok && value.S != nil {
+			name = entityName(*value.S, discriminator.Separator, pattern)
+		}
+
+		i, ok := indexes[name]
+		if !ok {
+			i = len(entities)
+			indexes[name] = i
+			entities = append(entities, Entity{Name: name})
+		}
+		entities[i].Items = append(entities[i].Items, item)
+	}
+
+	return entities, nil
+}
+
+func entityName(value string, separator string, pattern *regexp.Regexp) string {
+	if pattern != nil {
+		m := pattern.FindStringSubmatch(value)
+		if m == nil {
+			return ""
+		} else if len(m) > 1 {
+			return m[1]
+		}
+		return m[0]
+	}
+
+	if separator != "" {
+		if i := strings.Index(value, separator); i >= 0 {
+			return value[:i]
+		}
+		return ""
+	}
+
+	return value
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// ExplainRequest is the body of the explain resource: a query and the time range of the panel
+type ExplainRequest struct {
+	Query     QueryModel        `json:"query"`
+	TimeRange backend.TimeRange `json:"timeRange"`
+}
+
+// ExplainResponse describes how a query would be executed, without reading any items
+type ExplainResponse struct {
+	Table        string `json:"table"`
+	Index        string `json:"index,omitempty"`
+	Operation    string `json:"operation"`
+	PartitionKey string `json:"partitionKey"`
+	// Statement sent to DynamoDB, including the time filter
+	Statement string `json:"statement"`
+	// Approximate number of items of the table or index, all of which are read by a Scan
+	EstimatedItemCount int64 `json:"estimatedItemCount"`
+	SizeBytes          int64 `json:"sizeBytes"`
+	// Whether the items are filtered by time after fetching
+	PostFilter bool `json:"postFilter"`
+}
+
+// CallResource handles the resources of the datasource:
+//   - POST explain: returns the ExplainResponse of the ExplainRequest in the body
+func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	switch req.Path {
+	case "explain":
+		if req.Method != http.MethodPost {
+			return sendResourceError(sender, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+		}
+
+		explanation, err := d.explain(ctx, req)
+		if err != nil {
+			return sendResourceError(sender, http.StatusBadRequest, err)
+		}
+
+		body, err := json.Marshal(explanation)
+		if err != nil {
+			return sendResourceError(sender, http.StatusInternalServerError, err)
+		}
+
+		return sender.Send(&backend.CallResourceResponse{
+			Status:  http.StatusOK,
+			Headers: map[string][]string{"Content-Type": {"application/json"}},
+			Body:    body,
+		})
+	default:
+		return sendResourceError(sender, http.StatusNotFound, fmt.Errorf("resource %s not found", req.Path))
+	}
+}
+
+func (d *Datasource) explain(ctx context.Context, req *backend.CallResourceRequest) (*ExplainResponse, error) {
+	var explainRequest ExplainRequest
+	err := json.Unmarshal(req.Body, &explainRequest)
+	if err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	qm := explainRequest.Query
+	qm.QueryText, err = ApplyAccessRules(qm.QueryText, d.ExtraSettings.AccessRules, req.PluginContext.User)
+	if err != nil {
+		return nil, err
+	}
+
+	d.applyDefaults(&qm)
+	statement, timeFilter, err := timeFilterStatement(qm, newDataFrameOptions(qm), explainRequest.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.checkOverrides(qm.Region, qm.AssumeRoleARN)
+	if err != nil {
+		return nil, err
+	}
+
+	key := d.clientKey(qm.Region, qm.AssumeRoleARN)
+	dynamoDBClient, err := d.getDynamoDBClient(ctx, req.PluginContext.DataSourceInstanceSettings, key)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := d.planStatement(ctx, dynamoDBClient, key, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExplainResponse{
+		Table:              plan.Table,
+		Index:              plan.Index,
+		Operation:          plan.Operation,
+		PartitionKey:       plan.PartitionKey,
+		Statement:          statement,
+		EstimatedItemCount: plan.ItemCount,
+		SizeBytes:          plan.SizeBytes,
+		PostFilter:         timeFilter != nil,
+	}, nil
+}
+
+func sendResourceError(sender backend.CallResourceResponseSender, status int, err error) error {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
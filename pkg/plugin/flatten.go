@@ -0,0 +1,41 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// FlattenItems expands M attributes (and optionally L attributes) of every item into
+// attributes named "parent.child" and "parent[0]", up to maxDepth levels of nesting.
+// Values below the maximum depth are left untouched and become JSON columns.
+func FlattenItems(items []map[string]*dynamodb.AttributeValue, maxDepth int, listMode string) []map[string]*dynamodb.AttributeValue {
+	if maxDepth <= 0 {
+		return items
+	}
+
+	flattened := make([]map[string]*dynamodb.AttributeValue, len(items))
+	for i, item := range items {
+		row := make(map[string]*dynamodb.AttributeValue)
+		for name, value := range item {
+			flattenAttribute(row, name, value, maxDepth, listMode)
+		}
+		flattened[i] = row
+	}
+
+	return flattened
+}
+
+func flattenAttribute(row map[string]*dynamodb.AttributeValue, name string, value *dynamodb.AttributeValue, depth int, listMode string) {
+	if depth > 0 && value.M != nil && len(value.M) > 0 {
+		for k, v := range value.M {
+			flattenAttribute(row, name+"."+k, v, depth-1, listMode)
+		}
+	} else if depth > 0 && value.L != nil && len(value.L) > 0 && listMode == ListFlattenModeIndex {
+		for i, v := range value.L {
+			flattenAttribute(row, fmt.Sprintf("%s[%d]", name, i), v, depth-1, listMode)
+		}
+	} else {
+		row[name] = value
+	}
+}
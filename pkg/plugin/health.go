@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// HealthCheck is the result of one API call of the health check
+type HealthCheck struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// HealthDetails are the JSON details of the health check result
+type HealthDetails struct {
+	Region   string `json:"region"`
+	Endpoint string `json:"endpoint"`
+	// Round-trip latency of the first request
+	LatencyMs int64 `json:"latencyMs"`
+	// Table the permissions are probed on
+	Table  string        `json:"table,omitempty"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// CheckHealth handles health checks sent from Grafana to the plugin.
+// The main use case for these health checks is the test button on the
+// datasource configuration page which allows users to verify that
+// a datasource is working as expected.
+// The connection is tested with DescribeTable on the test table or, if none is set, with ListTables.
+// The permissions used by the plugin are then probed on the table, one check per API call.
+func (d *Datasource) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	backend.Logger.Debug("Checking health")
+	res := &backend.CheckHealthResult{}
+
+	session, err := d.getSession(ctx, req.PluginContext.DataSourceInstanceSettings, d.clientKey("", ""))
+	if err != nil {
+		res.Status = backend.HealthStatusError
+		res.Message = err.Error()
+		return res, nil
+	}
+
+	extraSettings, err := loadExtraPluginSettings(*req.PluginContext.DataSourceInstanceSettings)
+	if err != nil {
+		res.Status = backend.HealthStatusError
+		res.Message = err.Error()
+		return res, err
+	}
+
+	client := dynamodb.New(session)
+	details := HealthDetails{
+		Region:   aws.StringValue(client.Config.Region),
+		Endpoint: client.Endpoint,
+		Table:    extraSettings.ConnectionTestTable,
+	}
+
+	check := func(name string, call func() error) bool {
+		start := time.Now()
+		err := call()
+		result := HealthCheck{Name: name, OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		details.Checks = append(details.Checks, result)
+		return err == nil
+	}
+
+	connected := true
+	if details.Table == "" {
+		connected = check("ListTables", func() error {
+			output, err := client.ListTablesWithContext(ctx, &dynamodb.ListTablesInput{Limit: aws.Int64(1)})
+			if err == nil && len(output.TableNames) > 0 {
+				details.Table = aws.StringValue(output.TableNames[0])
+			}
+			return err
+		})
+	}
+
+	if details.Table != "" {
+		var table *dynamodb.TableDescription
+		ok := check("DescribeTable", func() error {
+			output, err := client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(details.Table)})
+			if err == nil {
+				table = output.Table
+			}
+			return err
+		})
+		if extraSettings.ConnectionTestTable != "" {
+			connected = ok
+		}
+
+		if table != nil {
+			check("PartiQLSelect", func() error {
+				_, err := client.ExecuteStatementWithContext(ctx, &dynamodb.ExecuteStatementInput{
+					Statement: aws.String("SELECT * FROM " + quoteIdentifier(details.Table)),
+					Limit:     aws.Int64(1),
+				})
+				return err
+			})
+			check("Query", func() error {
+				return probeQuery(ctx, client, table)
+			})
+			check("Scan", func() error {
+				_, err := client.ScanWithContext(ctx, &dynamodb.ScanInput{TableName: aws.String(details.Table), Limit: aws.Int64(1)})
+				return err
+			})
+			check("Streams", func() error {
+				return probeStreams(ctx, dynamodbstreams.New(session), table)
+			})
+		}
+	}
+
+	details.LatencyMs = details.Checks[0].LatencyMs
+
+	res.JSONDetails, err = json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if connected {
+		res.Status = backend.HealthStatusOk
+		lines = append(lines, fmt.Sprintf("Successfully connects to DynamoDB in %s (%d ms)", details.Region, details.LatencyMs))
+	} else {
+		res.Status = backend.HealthStatusError
+		lines = append(lines, fmt.Sprintf("Failed to connect to DynamoDB in %s", details.Region))
+	}
+	if details.Table == "" && connected {
+		lines = append(lines, "No table to check permissions on")
+	}
+	for _, c := range details.Checks {
+		if c.OK {
+			lines = append(lines, fmt.Sprintf("%s: OK", c.Name))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", c.Name, c.Error))
+		}
+	}
+	res.Message = strings.Join(lines, "\n")
+
+	return res, nil
+}
+
+// probeQuery queries the table with a partition key that most likely doesn't exist
+func probeQuery(ctx context.Context, client *dynamodb.DynamoDB, table *dynamodb.TableDescription) error {
+	var partitionKey string
+	for _, k := range table.KeySchema {
+		if aws.StringValue(k.KeyType) == dynamodb.KeyTypeHash {
+			partitionKey = aws.StringValue(k.AttributeName)
+		}
+	}
+
+	value := &dynamodb.AttributeValue{S: aws.String("grafana-health-check")}
+	for _, a := range table.AttributeDefinitions {
+		if aws.StringValue(a.AttributeName) != partitionKey {
+			continue
+		}
+		switch aws.StringValue(a.AttributeType) {
+		case dynamodb.ScalarAttributeTypeN:
+			value = &dynamodb.AttributeValue{N: aws.String("0")}
+		case dynamodb.ScalarAttributeTypeB:
+			value = &dynamodb.AttributeValue{B: []byte("grafana-health-check")}
+		}
+	}
+
+	_, err := client.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:                 table.TableName,
+		KeyConditionExpression:    aws.String("#pk = :pk"),
+		ExpressionAttributeNames:  map[string]*string{"#pk": aws.String(partitionKey)},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":pk": value},
+		Limit:                     aws.Int64(1),
+	})
+	return err
+}
+
+// probeStreams describes the stream of the table, or lists the streams if the table has none
+func probeStreams(ctx context.Context, client *dynamodbstreams.DynamoDBStreams, table *dynamodb.TableDescription) error {
+	if table.LatestStreamArn != nil {
+		_, err := client.DescribeStreamWithContext(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn: table.LatestStreamArn,
+			Limit:     aws.Int64(1),
+		})
+		return err
+	}
+
+	_, err := client.ListStreamsWithContext(ctx, &dynamodbstreams.ListStreamsInput{
+		TableName: table.TableName,
+		Limit:     aws.Int64(1),
+	})
+	return err
+}
@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// LogsFrame converts a frame into a dataplane log lines frame. The body is the body attribute, or the JSON
+// rendering of the whole item if no body attribute is set.
+func LogsFrame(frame *data.Frame, timeAttribute string, bodyAttribute string, severityAttribute string, labelAttributes []string) (*data.Frame, error) {
+	if timeAttribute == "" {
+		return nil, fmt.Errorf("logs output mode requires a primary time attribute")
+	}
+
+	timeField, _ := frame.FieldByName(timeAttribute)
+	size := 0
+	if timeField != nil {
+		size = timeField.Len()
+	}
+
+	timestamps := make([]time.Time, size)
+	for i := 0; i < size; i++ {
+		v, ok := timeField.ConcreteAt(i)
+		if !ok {
+			return nil, fmt.Errorf("time attribute %s has null values", timeAttribute)
+		}
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("time attribute %s should be a datetime attribute", timeAttribute)
+		}
+		timestamps[i] = t
+	}
+
+	bodies := make([]string, size)
+	if bodyAttribute != "" {
+		field := stringField(frame, bodyAttribute, size)
+		for i := 0; i < size; i++ {
+			if v, ok := field.ConcreteAt(i); ok {
+				bodies[i] = v.(string)
+			}
+		}
+	} else {
+		for i := 0; i < size; i++ {
+			body, err := rowToJson(frame, i)
+			if err != nil {
+				return nil, err
+			}
+			bodies[i] = string(body)
+		}
+	}
+
+	labels := make([]json.RawMessage, size)
+	labelFields := make([]*data.Field, len(labelAttributes))
+	for i, name := range labelAttributes {
+		labelFields[i] = stringField(frame, name, size)
+	}
+	for i := 0; i < size; i++ {
+		l := make(map[string]string)
+		for _, f := range labelFields {
+			if v, ok := f.ConcreteAt(i); ok {
+				l[f.Name] = v.(string)
+			}
+		}
+		b, err := json.Marshal(l)
+		if err != nil {
+			return nil, err
+		}
+		labels[i] = b
+	}
+
+	logsFrame := data.NewFrame(frame.Name,
+		data.NewField("timestamp", nil, timestamps),
+		data.NewField("body", nil, bodies),
+	)
+
+	if severityAttribute != "" {
+		severity := stringField(frame, severityAttribute, size)
+		severity.Name = "severity"
+		logsFrame.Fields = append(logsFrame.Fields, severity)
+	}
+
+	logsFrame.Fields = append(logsFrame.Fields, data.NewField("labels", nil, labels))
+	logsFrame.Meta = &data.FrameMeta{
+		Type:                   data.FrameTypeLogLines,
+		TypeVersion:            data.FrameTypeVersion{0, 0},
+		PreferredVisualization: data.VisTypeLogs,
+	}
+
+	return logsFrame, nil
+}
+
+// rowToJson renders the non-null values of a row as a JSON object
+func rowToJson(frame *data.Frame, row int) ([]byte, error) {
+	item := make(map[string]interface{})
+	for _, f := range frame.Fields {
+		if v, ok := f.ConcreteAt(row); ok {
+			item[f.Name] = v
+		}
+	}
+	return json.Marshal(item)
+}
@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// NodeGraphFrames converts a frame of adjacency list items into the nodes and edges frames of the node graph
+func NodeGraphFrames(frame *data.Frame, mapping NodeGraphMapping) (data.Frames, error) {
+	if mapping.NodeID == "" || mapping.EdgeSource == "" || mapping.EdgeTarget == "" {
+		return nil, fmt.Errorf("node graph output mode requires node ID, edge source and edge target attributes")
+	}
+
+	size, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	trim := func(id string) string {
+		for _, prefix := range mapping.TrimPrefixes {
+			if strings.HasPrefix(id, prefix) {
+				return strings.TrimPrefix(id, prefix)
+			}
+		}
+		return id
+	}
+
+	nodeIDs := stringField(frame, mapping.NodeID, size)
+	sources := stringField(frame, mapping.EdgeSource, size)
+	targets := stringField(frame, mapping.EdgeTarget, size)
+	titles := stringField(frame, mapping.NodeTitle, size)
+	mainStats, _ := frame.FieldByName(mapping.NodeMainStat)
+	secondaryStats, _ := frame.FieldByName(mapping.NodeSecondaryStat)
+
+	nodes := data.NewFrame("nodes",
+		data.NewField("id", nil, []string{}),
+		data.NewField("title", nil, []*string{}),
+	)
+	if mainStats != nil {
+		nodes.Fields = append(nodes.Fields, data.NewFieldFromFieldType(mainStats.Type(), 0))
+		nodes.Fields[len(nodes.Fields)-1].Name = "mainstat"
+	}
+	if secondaryStats != nil {
+		nodes.Fields = append(nodes.Fields, data.NewFieldFromFieldType(secondaryStats.Type(), 0))
+		nodes.Fields[len(nodes.Fields)-1].Name = "secondarystat"
+	}
+
+	edges := data.NewFrame("edges",
+		data.NewField("id", nil, []string{}),
+		data.NewField("source", nil, []string{}),
+		data.NewField("target", nil, []string{}),
+	)
+
+	hasNode := make(map[string]bool)
+	var edgeNodes []string
+	for i := 0; i < size; i++ {
+		source, _ := sources.ConcreteAt(i)
+		target, _ := targets.ConcreteAt(i)
+		var s, t string
+		if source != nil && target != nil {
+			s, t = trim(source.(string)), trim(target.(string))
+		}
+		// Node items of adjacency lists reference themselves, e.g. PK=NODE#a and SK=NODE#a
+		isEdge := source != nil && target != nil && s != t && (mapping.EdgePrefix == "" || strings.HasPrefix(target.(string), mapping.EdgePrefix))
+
+		if isEdge {
+			edges.AppendRow(s+"->"+t, s, t)
+			edgeNodes = append(edgeNodes, s, t)
+			continue
+		}
+
+		id, ok := nodeIDs.ConcreteAt(i)
+		if !ok || hasNode[trim(id.(string))] {
+			continue
+		}
+		hasNode[trim(id.(string))] = true
+
+		row := []interface{}{trim(id.(string)), titles.CopyAt(i)}
+		if mainStats != nil {
+			row = append(row, mainStats.CopyAt(i))
+		}
+		if secondaryStats != nil {
+			row = append(row, secondaryStats.CopyAt(i))
+		}
+		nodes.AppendRow(row...)
+	}
+
+	// Edges may reference nodes that aren't stored as items
+	for _, id := range edgeNodes {
+		if hasNode[id] {
+			continue
+		}
+		hasNode[id] = true
+
+		row := []interface{}{id, nil}
+		for range nodes.Fields[2:] {
+			row = append(row, nil)
+		}
+		nodes.AppendRow(row...)
+	}
+
+	meta := &data.FrameMeta{PreferredVisualization: data.VisTypeNodeGraph}
+	nodes.Meta = meta
+	edges.Meta = meta
+
+	return data.Frames{nodes, edges}, nil
+}
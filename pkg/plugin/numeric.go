@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// NumericFrame converts a frame into a dataplane numeric frame of the value attribute, with the
+// dimension attributes as string fields (long format) or as labels of one field per item (wide format)
+func NumericFrame(frame *data.Frame, valueAttribute string, dimensionAttributes []string, format string) (*data.Frame, error) {
+	if valueAttribute == "" {
+		return nil, fmt.Errorf("numeric output mode requires a value attribute")
+	}
+
+	valueField, _ := frame.FieldByName(valueAttribute)
+	if valueField == nil {
+		// A result without items has no fields, which is an empty numeric frame rather than a misconfiguration
+		if frame.Rows() > 0 {
+			return nil, fmt.Errorf("value attribute %s not found", valueAttribute)
+		}
+		valueField = data.NewField(valueAttribute, nil, []*float64{})
+	} else if !valueField.Type().Numeric() {
+		return nil, fmt.Errorf("value attribute %s should be a number, but got %s", valueAttribute, valueField.Type().ItemTypeString())
+	}
+
+	dimensions := make([]*data.Field, len(dimensionAttributes))
+	for i, name := range dimensionAttributes {
+		dimensions[i] = stringField(frame, name, valueField.Len())
+	}
+
+	numericFrame := data.NewFrame(frame.Name)
+	switch format {
+	case "", NumericFormatLong:
+		numericFrame.Fields = append([]*data.Field{copyField(valueField)}, dimensions...)
+		numericFrame.Meta = &data.FrameMeta{
+			Type:        data.FrameTypeNumericLong,
+			TypeVersion: data.FrameTypeVersion{0, 1},
+		}
+	case NumericFormatWide:
+		for row := 0; row < valueField.Len(); row++ {
+			f := data.NewFieldFromFieldType(valueField.Type(), 1)
+			f.Name = valueAttribute
+			f.Set(0, valueField.CopyAt(row))
+			f.Labels = data.Labels{}
+			for _, d := range dimensions {
+				if v, ok := d.ConcreteAt(row); ok {
+					f.Labels[d.Name] = v.(string)
+				}
+			}
+			numericFrame.Fields = append(numericFrame.Fields, f)
+		}
+		numericFrame.Meta = &data.FrameMeta{
+			Type:        data.FrameTypeNumericWide,
+			TypeVersion: data.FrameTypeVersion{0, 1},
+		}
+	default:
+		return nil, fmt.Errorf("invalid numeric format %s", format)
+	}
+
+	return numericFrame, nil
+}
+
+// stringField returns the values of the attribute as a string field, with nulls if the frame doesn't have it
+func stringField(frame *data.Frame, name string, size int) *data.Field {
+	field := data.NewField(name, nil, make([]*string, size))
+	f, _ := frame.FieldByName(name)
+	if f == nil {
+		return field
+	}
+
+	for i := 0; i < f.Len() && i < size; i++ {
+		v, ok := f.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+
+		switch c := v.(type) {
+		case string:
+			field.Set(i, Pointer(c))
+		case json.RawMessage:
+			field.Set(i, Pointer(string(c)))
+		case time.Time:
+			field.Set(i, Pointer(c.Format(time.RFC3339Nano)))
+		default:
+			field.Set(i, Pointer(fmt.Sprint(c)))
+		}
+	}
+	return field
+}
+
+func copyField(field *data.Field) *data.Field {
+	rows := make([]int, field.Len())
+	for i := range rows {
+		rows[i] = i
+	}
+	return reorderField(field, rows)
+}
@@ -0,0 +1,41 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// outputFrames sorts the frame by the primary time attribute and converts it into the frames of the output mode
+func outputFrames(frame *data.Frame, qm QueryModel) (data.Frames, error) {
+	var err error
+	if qm.PrimaryTimeAttribute != "" {
+		err = SetPrimaryTimeField(frame, qm.PrimaryTimeAttribute)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frames := data.Frames{frame}
+	switch qm.OutputMode {
+	case "", OutputModeTable:
+	case OutputModeNumeric:
+		frame, err = NumericFrame(frame, qm.ValueAttribute, qm.DimensionAttributes, qm.NumericFormat)
+		frames = data.Frames{frame}
+	case OutputModeLogs:
+		frame, err = LogsFrame(frame, qm.PrimaryTimeAttribute, qm.BodyAttribute, qm.SeverityAttribute, qm.LabelAttributes)
+		frames = data.Frames{frame}
+	case OutputModeTrace:
+		frame, err = TraceFrame(frame, qm.TraceMapping)
+		frames = data.Frames{frame}
+	case OutputModeNodeGraph:
+		frames, err = NodeGraphFrames(frame, qm.NodeGraphMapping)
+	default:
+		err = fmt.Errorf("invalid output mode %s", qm.OutputMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return frames, nil
+}
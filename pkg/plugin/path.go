@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// parsePath splits a path expression like "meta.events[0].at" into map keys and list indexes
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		var indexes []int
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if rest[0] != '[' || end < 0 {
+					return nil, fmt.Errorf("invalid path %s", path)
+				}
+				index, err := strconv.Atoi(rest[1:end])
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid list index in path %s", path)
+				}
+				indexes = append(indexes, index)
+				rest = rest[end+1:]
+			}
+		}
+
+		if key == "" && (len(segments) == 0 || len(indexes) == 0) {
+			return nil, fmt.Errorf("invalid path %s", path)
+		}
+		if key != "" {
+			segments = append(segments, pathSegment{key: key, index: -1})
+		}
+		for _, index := range indexes {
+			segments = append(segments, pathSegment{index: index})
+		}
+	}
+
+	return segments, nil
+}
+
+// evaluatePath returns the value at the path, or nil if the path doesn't exist in the item
+func evaluatePath(item map[string]*dynamodb.AttributeValue, segments []pathSegment) *dynamodb.AttributeValue {
+	value := &dynamodb.AttributeValue{M: item}
+	for _, s := range segments {
+		if s.index < 0 {
+			if value.M == nil {
+				return nil
+			}
+			value = value.M[s.key]
+		} else {
+			if value.L == nil || s.index >= len(value.L) {
+				return nil
+			}
+			value = value.L[s.index]
+		}
+		if value == nil {
+			return nil
+		}
+	}
+
+	return value
+}
+
+// ExtractAttributes evaluates the path of each extracted attribute against every item and
+// stores the result in the item under the name of the extracted attribute
+func ExtractAttributes(items []map[string]*dynamodb.AttributeValue, extractedAttributes []ExtractedAttribute) error {
+	for _, ea := range extractedAttributes {
+		if ea.Name == "" {
+			return fmt.Errorf("extracted attribute with path %s has no name", ea.Path)
+		}
+
+		segments, err := parsePath(ea.Path)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			value := evaluatePath(item, segments)
+			if value == nil {
+				continue
+			}
+
+			v, err := convertAttributeValue(value, ea.Type)
+			if err != nil {
+				return fmt.Errorf("extracted attribute %s: %w", ea.Name, err)
+			}
+			item[ea.Name] = v
+		}
+	}
+
+	return nil
+}
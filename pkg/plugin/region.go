@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Attribute the region of an item is stored in when a query fans out to several regions. Items and extracted
+// attributes can't use this name in such queries
+const RegionAttribute = "region"
+
+type regionResult struct {
+	items            []map[string]*dynamodb.AttributeValue
+	consumedCapacity float64
+	err              error
+}
+
+// executeInRegions executes the statement in every region at the same time and returns the union of the items,
+// each tagged with its region, and the total consumed capacity. Regions that fail are returned as warning notices,
+// unless all of them fail. Items that already have the region attribute are an error, since tagging them would
+// overwrite it. A single region is executed without tagging the items
+func (d *Datasource) executeInRegions(ctx context.Context, settings *backend.DataSourceInstanceSettings, regions []string, assumeRoleARN string, input *dynamodb.ExecuteStatementInput) ([]map[string]*dynamodb.AttributeValue, float64, []data.Notice, error) {
+	execute := func(region string) regionResult {
+		dynamoDBClient, err := d.getDynamoDBClient(ctx, settings, d.clientKey(region, assumeRoleARN))
+		if err != nil {
+			return regionResult{err: err}
+		}
+
+		output, err := dynamoDBClient.ExecuteStatementWithContext(ctx, input)
+		if err != nil {
+			return regionResult{err: fmt.Errorf("executes statement: %w", err)}
+		}
+
+		result := regionResult{items: output.Items}
+		if output.ConsumedCapacity != nil {
+			result.consumedCapacity = aws.Float64Value(output.ConsumedCapacity.CapacityUnits)
+		}
+		return result
+	}
+
+	if len(regions) == 1 {
+		result := execute(regions[0])
+		return result.items, result.consumedCapacity, nil, result.err
+	}
+
+	results := make([]regionResult, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			results[i] = execute(region)
+		}(i, region)
+	}
+	wg.Wait()
+
+	var items []map[string]*dynamodb.AttributeValue
+	var consumedCapacity float64
+	var notices []data.Notice
+	var lastErr error
+	for i, result := range results {
+		consumedCapacity += result.consumedCapacity
+		if result.err != nil {
+			backend.Logger.Warn("Query failed in region", "region", regions[i], "error", result.err.Error())
+			notices = append(notices, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("region %s: %s", regions[i], result.err.Error()),
+			})
+			lastErr = result.err
+			continue
+		}
+
+		for _, item := range result.items {
+			if _, ok := item[RegionAttribute]; ok {
+				return nil, consumedCapacity, nil, fmt.Errorf("items of region %s have a %s attribute, which is reserved for the region when querying several regions", regions[i], RegionAttribute)
+			}
+			item[RegionAttribute] = &dynamodb.AttributeValue{S: aws.String(regions[i])}
+		}
+		items = append(items, result.items...)
+	}
+
+	if len(notices) == len(regions) {
+		return nil, consumedCapacity, nil, fmt.Errorf("all regions failed: %w", lastErr)
+	}
+
+	return items, consumedCapacity, notices, nil
+}
+
+// SplitEntitiesByRegion splits every entity into one entity per region, in the order of the regions. The region
+// attribute is removed from the items and becomes a label of the entity
+func SplitEntitiesByRegion(entities []Entity, regions []string, keepNames bool) []Entity {
+	var split []Entity
+	for _, entity := range entities {
+		byRegion := make(map[string][]map[string]*dynamodb.AttributeValue)
+		for _, item := range entity.Items {
+			region := ""
+			if value, ok := item[RegionAttribute]; ok && value.S != nil {
+				region = *value.S
+			}
+			delete(item, RegionAttribute)
+			byRegion[region] = append(byRegion[region], item)
+		}
+
+		for _, region := range regions {
+			items, ok := byRegion[region]
+			if !ok {
+				continue
+			}
+
+			name := region
+			if keepNames {
+				name = entity.Name + " " + region
+			}
+			split = append(split, Entity{Name: name, Items: items, Labels: data.Labels{RegionAttribute: region}})
+		}
+	}
+
+	return split
+}
@@ -0,0 +1,230 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	OperationQuery = "Query"
+	OperationScan  = "Scan"
+)
+
+// How long table descriptions are cached
+const tableDescriptionTTL = 10 * time.Minute
+
+// StatementPlan describes how DynamoDB executes a SELECT statement
+type StatementPlan struct {
+	Table string
+	// Index the statement reads from, empty for the base table
+	Index string
+	// OperationQuery if the statement selects a partition key, OperationScan otherwise
+	Operation string
+	// Partition key of the table or index
+	PartitionKey string
+	// Approximate number of items and size of the table or index, updated by DynamoDB about every six hours
+	ItemCount int64
+	SizeBytes int64
+}
+
+type tableDescriptionCache struct {
+	mu     sync.Mutex
+	tables map[tableKey]cachedTableDescription
+}
+
+type tableKey struct {
+	client clientKey
+	name   string
+}
+
+type cachedTableDescription struct {
+	table     *dynamodb.TableDescription
+	fetchedAt time.Time
+}
+
+// describeTable returns the description of the table, calling DescribeTable at most once per tableDescriptionTTL
+func (c *tableDescriptionCache) describeTable(ctx context.Context, client *dynamodb.DynamoDB, key clientKey, name string) (*dynamodb.TableDescription, error) {
+	c.mu.Lock()
+	cached, ok := c.tables[tableKey{client: key, name: name}]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < tableDescriptionTTL {
+		return cached.table, nil
+	}
+
+	output, err := client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe table %s: %w", name, err)
+	}
+
+	c.mu.Lock()
+	if c.tables == nil {
+		c.tables = make(map[tableKey]cachedTableDescription)
+	}
+	c.tables[tableKey{client: key, name: name}] = cachedTableDescription{table: output.Table, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return output.Table, nil
+}
+
+// Matches a condition on an attribute at the start of a conjunct, e.g. pk = 'a' or "pk" IN ['a', 'b']
+var keyConditionRegex = regexp.MustCompile(`(?is)^("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_]*)\s*(?:=|IN\b)`)
+
+// Matches a condition on an attribute at the end of a conjunct, e.g. 'a' = pk, but not 'a' <= pk or 'a' != pk
+var reversedKeyConditionRegex = regexp.MustCompile(`(?is)(?:^|[^<>!=])=\s*("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_]*)$`)
+
+// AnalyzeStatement returns whether the SELECT statement is executed as a Query or a Scan of the table.
+// A statement is a Query if its WHERE clause requires an equality or IN condition on the partition key of
+// the table or index it reads from
+func AnalyzeStatement(statement string, table *dynamodb.TableDescription) (StatementPlan, error) {
+	tableName, index := parseTableName(statement)
+	plan := StatementPlan{
+		Table:     tableName,
+		Index:     index,
+		Operation: OperationScan,
+	}
+
+	keySchema := table.KeySchema
+	plan.ItemCount = aws.Int64Value(table.ItemCount)
+	plan.SizeBytes = aws.Int64Value(table.TableSizeBytes)
+	if index != "" {
+		keySchema = nil
+		for _, i := range table.GlobalSecondaryIndexes {
+			if aws.StringValue(i.IndexName) == index {
+				keySchema = i.KeySchema
+				plan.ItemCount = aws.Int64Value(i.ItemCount)
+				plan.SizeBytes = aws.Int64Value(i.IndexSizeBytes)
+			}
+		}
+		for _, i := range table.LocalSecondaryIndexes {
+			if aws.StringValue(i.IndexName) == index {
+				keySchema = i.KeySchema
+				plan.ItemCount = aws.Int64Value(i.ItemCount)
+				plan.SizeBytes = aws.Int64Value(i.IndexSizeBytes)
+			}
+		}
+		if keySchema == nil {
+			return plan, fmt.Errorf("index %s of table %s not found", index, tableName)
+		}
+	}
+
+	for _, k := range keySchema {
+		if aws.StringValue(k.KeyType) == dynamodb.KeyTypeHash {
+			plan.PartitionKey = aws.StringValue(k.AttributeName)
+		}
+	}
+
+	if hasKeyCondition(whereClause(statement), plan.PartitionKey) {
+		plan.Operation = OperationQuery
+	}
+
+	return plan, nil
+}
+
+// whereClause returns the condition of the WHERE clause of the statement. Statements with unbalanced brackets
+// have no condition, since a bracket closed too early can turn a key condition into one side of an OR
+func whereClause(statement string) string {
+	statement = strings.TrimRight(strings.TrimSpace(statement), ";")
+	where := keywordPositions(statement, "WHERE")
+	if len(where) != 1 || !balanced(statement) {
+		return ""
+	}
+
+	end := len(statement)
+	if orderBy := keywordPositions(statement, "ORDER"); len(orderBy) == 1 && orderBy[0] > where[0] {
+		end = orderBy[0]
+	}
+
+	return strings.TrimSpace(statement[where[0]+len("WHERE") : end])
+}
+
+// hasKeyCondition reports whether the condition can only be true for items with the given partition keys
+func hasKeyCondition(condition string, partitionKey string) bool {
+	condition = unwrapParentheses(condition)
+	if condition == "" || partitionKey == "" || len(keywordPositions(condition, "OR")) > 0 {
+		return false
+	}
+
+	start := 0
+	for _, end := range append(keywordPositions(condition, "AND"), len(condition)) {
+		conjunct := strings.TrimSpace(condition[start:end])
+		start = end + len("AND")
+
+		if unwrapped := unwrapParentheses(conjunct); unwrapped != conjunct {
+			if hasKeyCondition(unwrapped, partitionKey) {
+				return true
+			}
+			continue
+		}
+
+		if m := keyConditionRegex.FindStringSubmatch(conjunct); m != nil && unquoteIdentifier(m[1]) == partitionKey {
+			return true
+		}
+		if m := reversedKeyConditionRegex.FindStringSubmatch(conjunct); m != nil && unquoteIdentifier(m[1]) == partitionKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unwrapParentheses removes the parentheses around the whole condition
+func unwrapParentheses(condition string) string {
+	condition = strings.TrimSpace(condition)
+	for strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+		inner := condition[1 : len(condition)-1]
+		// (a) AND (b) is not wrapped as a whole
+		if !balanced(inner) {
+			break
+		}
+		condition = strings.TrimSpace(inner)
+	}
+	return condition
+}
+
+// balanced reports whether the brackets outside of literals are balanced, never closing more than opened
+// or a different kind of bracket than the last one opened
+func balanced(s string) bool {
+	var open []byte
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"':
+			quote := s[i]
+			for i++; i < len(s); i++ {
+				if s[i] == quote {
+					if i+1 < len(s) && s[i+1] == quote {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+		case '(', '[', '{':
+			open = append(open, s[i])
+		case ')', ']', '}':
+			if len(open) == 0 || strings.IndexByte("([{", open[len(open)-1]) != strings.IndexByte(")]}", s[i]) {
+				return false
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	return len(open) == 0
+}
+
+// isScanAllowed reports whether the administrator allowed scans of the table
+func isScanAllowed(settings ExtraPluginSettings, table string) bool {
+	for _, t := range settings.ScanAllowedTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
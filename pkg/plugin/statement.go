@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matches the target of a PartiQL SELECT after the FROM keyword, e.g. Orders, "Orders" or "Orders"."byDate"
+var tableRegex = regexp.MustCompile(`(?is)^\s*("(?:[^"]|"")+"|[A-Za-z0-9_.\-]+)(?:\s*\.\s*("(?:[^"]|"")+"|[A-Za-z0-9_.\-]+))?`)
+
+// parseTableName returns the table and, if any, the index the statement reads from. It returns an empty
+// table if the statement doesn't have exactly one FROM keyword outside of literals, parentheses and comments
+func parseTableName(statement string) (string, string) {
+	from := keywordPositions(statement, "FROM")
+	if len(from) != 1 {
+		return "", ""
+	}
+
+	m := tableRegex.FindStringSubmatch(statement[from[0]+len("FROM"):])
+	if m == nil {
+		return "", ""
+	}
+
+	table := m[1]
+	index := m[2]
+	if !strings.HasPrefix(table, `"`) && index == "" {
+		// Unquoted names can't contain dots, so Orders.byDate is table Orders and index byDate
+		if i := strings.Index(table, "."); i >= 0 {
+			table, index = table[:i], table[i+1:]
+		}
+	}
+
+	return unquoteIdentifier(table), unquoteIdentifier(index)
+}
+
+// isWriteStatement reports whether the statement is an INSERT, UPDATE or DELETE statement
+func isWriteStatement(statement string) bool {
+	switch firstKeyword(statement) {
+	case "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// firstKeyword returns the first word of the statement in upper case, skipping leading comments
+func firstKeyword(statement string) string {
+	for i := 0; i < len(statement); i++ {
+		if end, ok := commentEnd(statement, i); ok {
+			i = end - 1
+			continue
+		}
+		if strings.ContainsRune(" \t\r\n", rune(statement[i])) {
+			continue
+		}
+
+		end := i
+		for end < len(statement) && !isWordBoundary(statement, end) {
+			end++
+		}
+		return strings.ToUpper(statement[i:end])
+	}
+	return ""
+}
+
+// commentEnd returns the position after the comment starting at i, if any
+func commentEnd(statement string, i int) (int, bool) {
+	switch {
+	case strings.HasPrefix(statement[i:], "--"):
+		if end := strings.IndexByte(statement[i:], '\n'); end >= 0 {
+			return i + end + 1, true
+		}
+		return len(statement), true
+	case strings.HasPrefix(statement[i:], "/*"):
+		if end := strings.Index(statement[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2, true
+		}
+		return len(statement), true
+	}
+	return 0, false
+}
+
+func unquoteIdentifier(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// keywordPositions returns the positions of the keywords in the statement that aren't inside
+// string literals, quoted identifiers, parentheses or comments
+func keywordPositions(statement string, keyword string) []int {
+	var positions []int
+	upper := strings.ToUpper(statement)
+	depth := 0
+	for i := 0; i < len(statement); i++ {
+		if end, ok := commentEnd(statement, i); ok {
+			i = end - 1
+			continue
+		}
+
+		switch statement[i] {
+		case '\'', '"':
+			// Skip the literal, quotes are escaped by doubling them
+			quote := statement[i]
+			for i++; i < len(statement); i++ {
+				if statement[i] == quote {
+					if i+1 < len(statement) && statement[i+1] == quote {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(upper[i:], keyword) && isWordBoundary(statement, i-1) && isWordBoundary(statement, i+len(keyword)) {
+				positions = append(positions, i)
+			}
+		}
+	}
+	return positions
+}
+
+func isWordBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z')
+}
+
+// quoteIdentifier quotes an attribute name for PartiQL
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// hasComment reports whether the statement contains a comment outside of string literals and quoted identifiers
+func hasComment(statement string) bool {
+	for i := 0; i < len(statement); i++ {
+		switch statement[i] {
+		case '\'', '"':
+			quote := statement[i]
+			for i++; i < len(statement); i++ {
+				if statement[i] == quote {
+					if i+1 < len(statement) && statement[i+1] == quote {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+		case '-', '/':
+			if _, ok := commentEnd(statement, i); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addCondition adds a condition to the WHERE clause of a SELECT statement. It returns false if the
+// statement can't be rewritten safely, e.g. because a trailing comment would swallow the condition or
+// its brackets are unbalanced.
+func addCondition(statement string, condition string) (string, bool) {
+	statement = strings.TrimRight(strings.TrimSpace(statement), ";")
+	// Unbalanced brackets could close the parentheses around the existing condition, e.g. a = 1) OR (1 = 1
+	if !strings.HasPrefix(strings.ToUpper(statement), "SELECT") || hasComment(statement) || !balanced(statement) {
+		return "", false
+	}
+
+	where := keywordPositions(statement, "WHERE")
+	orderBy := keywordPositions(statement, "ORDER")
+	if len(where) > 1 || len(orderBy) > 1 {
+		return "", false
+	}
+
+	end := len(statement)
+	if len(orderBy) == 1 {
+		end = orderBy[0]
+		if len(where) == 1 && where[0] > end {
+			return "", false
+		}
+	}
+
+	tail := statement[end:]
+	if len(where) == 0 {
+		return strings.TrimRight(statement[:end], " \t\r\n") + " WHERE " + condition + suffix(tail), true
+	}
+
+	existing := strings.TrimSpace(statement[where[0]+len("WHERE") : end])
+	if existing == "" {
+		return "", false
+	}
+	return statement[:where[0]] + "WHERE (" + existing + ") AND " + condition + suffix(tail), true
+}
+
+func suffix(tail string) string {
+	if tail == "" {
+		return ""
+	}
+	return " " + tail
+}
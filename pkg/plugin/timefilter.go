@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// epochUnit returns the unit of an epoch datetime format, or false if the format isn't an epoch format
+func epochUnit(format string) (time.Duration, bool) {
+	switch format {
+	case UnixTimestampSeconds, DatetimeFormatUnixSeconds:
+		return time.Second, true
+	case UnixTimestampMiniseconds, DatetimeFormatUnixMilliseconds:
+		return time.Millisecond, true
+	case DatetimeFormatUnixMicroseconds:
+		return time.Microsecond, true
+	case DatetimeFormatUnixNanoseconds:
+		return time.Nanosecond, true
+	}
+	return 0, false
+}
+
+// addTimeFilter restricts the statement to the time range with BETWEEN conditions on the attribute.
+// This is only possible for top level attributes stored as Unix timestamps, since other formats don't
+// compare by time in DynamoDB. Timestamps stored as strings compare as text, which matches their numeric
+// order only if they have as many digits as the bounds, so the items still need to be filtered afterwards.
+func addTimeFilter(statement string, name string, format string, timeRange backend.TimeRange) (string, bool) {
+	unit, ok := epochUnit(format)
+	if !ok || strings.ContainsAny(name, ".[") {
+		return "", false
+	}
+
+	from := strconv.FormatInt(timeRange.From.UnixNano()/int64(unit), 10)
+	// Round up so that values within the last unit are included
+	to := strconv.FormatInt((timeRange.To.UnixNano()+int64(unit)-1)/int64(unit), 10)
+	if len(from) != len(to) {
+		// Strings within the range may have fewer digits than the upper bound and sort after it
+		return "", false
+	}
+
+	attribute := quoteIdentifier(name)
+	return addCondition(statement, fmt.Sprintf("(%s BETWEEN %s AND %s OR %s BETWEEN '%s' AND '%s')", attribute, from, to, attribute, from, to))
+}
+
+// FilterItemsByTime keeps the items whose datetime attribute is within the time range
+func FilterItemsByTime(items []map[string]*dynamodb.AttributeValue, name string, options *DatetimeOptions, timeRange backend.TimeRange) ([]map[string]*dynamodb.AttributeValue, error) {
+	var filtered []map[string]*dynamodb.AttributeValue
+	for _, item := range items {
+		value, ok := item[name]
+		if !ok || value.NULL != nil {
+			continue
+		}
+
+		t, err := parseDatetime(value, options)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+
+		if !t.Before(timeRange.From) && !t.After(timeRange.To) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered, nil
+}
+
+// timeFilterStatement returns the statement restricted to the time range of the query where possible, and
+// the options to filter the items by time after fetching. The items are always filtered afterwards, since
+// the condition added to the statement may also match items outside of the time range.
+func timeFilterStatement(qm QueryModel, options DataFrameOptions, timeRange backend.TimeRange) (string, *DatetimeOptions, error) {
+	if qm.TimeFilterAttribute == "" {
+		return qm.QueryText, nil, nil
+	}
+
+	attributeOptions := options.attributeOptions(qm.TimeFilterAttribute)
+	if attributeOptions.DatetimeFormat == "" {
+		return "", nil, fmt.Errorf("time filter attribute %s should be a datetime attribute", qm.TimeFilterAttribute)
+	}
+
+	timeFilter, err := newDatetimeOptions(attributeOptions.DatetimeFormat, attributeOptions.DatetimeFallbacks, attributeOptions.DatetimeTimezone)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Values in a fallback format wouldn't match the condition
+	if !isExtractedAttribute(qm, qm.TimeFilterAttribute) && len(attributeOptions.DatetimeFallbacks) == 0 {
+		if filtered, ok := addTimeFilter(qm.QueryText, qm.TimeFilterAttribute, attributeOptions.DatetimeFormat, timeRange); ok {
+			return filtered, timeFilter, nil
+		}
+	}
+
+	return qm.QueryText, timeFilter, nil
+}
+
+// isExtractedAttribute reports whether the attribute is computed from other attributes and is not stored in the table
+func isExtractedAttribute(qm QueryModel, name string) bool {
+	for _, ea := range qm.ExtractedAttributes {
+		if ea.Name == name {
+			return true
+		}
+	}
+	return isCompositePart(qm, name)
+}
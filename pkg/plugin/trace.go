@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+var durationUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+type traceTag struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// TraceFrame converts a frame of span items into the frame the trace view expects
+func TraceFrame(frame *data.Frame, mapping TraceMapping) (*data.Frame, error) {
+	if mapping.TraceID == "" || mapping.SpanID == "" || mapping.StartTime == "" || mapping.Duration == "" {
+		return nil, fmt.Errorf("trace output mode requires trace ID, span ID, start time and duration attributes")
+	}
+
+	durationUnit := time.Millisecond
+	if mapping.DurationUnit != "" {
+		unit, ok := durationUnits[mapping.DurationUnit]
+		if !ok {
+			return nil, fmt.Errorf("invalid duration unit %s", mapping.DurationUnit)
+		}
+		durationUnit = unit
+	}
+
+	size, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	startTimes := make([]float64, size)
+	startTimeField, _ := frame.FieldByName(mapping.StartTime)
+	durations := make([]float64, size)
+	durationField, _ := frame.FieldByName(mapping.Duration)
+	for i := 0; i < size; i++ {
+		startTime, err := milliseconds(startTimeField, i, time.Millisecond)
+		if err != nil {
+			return nil, fmt.Errorf("start time attribute %s: %w", mapping.StartTime, err)
+		}
+		startTimes[i] = startTime
+
+		duration, err := milliseconds(durationField, i, durationUnit)
+		if err != nil {
+			return nil, fmt.Errorf("duration attribute %s: %w", mapping.Duration, err)
+		}
+		durations[i] = duration
+	}
+
+	// All attributes that aren't mapped to a span field are tags by default
+	tagAttributes := mapping.Tags
+	if len(tagAttributes) == 0 {
+		mapped := map[string]bool{
+			mapping.TraceID: true, mapping.SpanID: true, mapping.ParentSpanID: true, mapping.ServiceName: true,
+			mapping.OperationName: true, mapping.StartTime: true, mapping.Duration: true,
+		}
+		for _, f := range frame.Fields {
+			if !mapped[f.Name] {
+				tagAttributes = append(tagAttributes, f.Name)
+			}
+		}
+	}
+
+	tags := make([]json.RawMessage, size)
+	for i := 0; i < size; i++ {
+		t := []traceTag{}
+		for _, name := range tagAttributes {
+			f, _ := frame.FieldByName(name)
+			if f == nil {
+				continue
+			}
+			if v, ok := f.ConcreteAt(i); ok {
+				t = append(t, traceTag{Key: name, Value: v})
+			}
+		}
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = b
+	}
+
+	traceFrame := data.NewFrame(frame.Name,
+		renamedField(stringField(frame, mapping.TraceID, size), "traceID"),
+		renamedField(stringField(frame, mapping.SpanID, size), "spanID"),
+		renamedField(stringField(frame, mapping.ParentSpanID, size), "parentSpanID"),
+		renamedField(stringField(frame, mapping.ServiceName, size), "serviceName"),
+		renamedField(stringField(frame, mapping.OperationName, size), "operationName"),
+		data.NewField("startTime", nil, startTimes),
+		data.NewField("duration", nil, durations),
+		data.NewField("tags", nil, tags),
+	)
+	traceFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTrace,
+	}
+
+	return traceFrame, nil
+}
+
+// milliseconds returns a datetime value as milliseconds since the Unix epoch, or a number in the given unit
+// as milliseconds
+func milliseconds(field *data.Field, row int, unit time.Duration) (float64, error) {
+	if field == nil {
+		return 0, fmt.Errorf("missing attribute")
+	}
+
+	v, ok := field.ConcreteAt(row)
+	if !ok {
+		return 0, fmt.Errorf("null value")
+	}
+
+	switch n := v.(type) {
+	case time.Time:
+		return float64(n.UnixNano()) / float64(time.Millisecond), nil
+	case int64:
+		return float64(n) * float64(unit) / float64(time.Millisecond), nil
+	case float64:
+		return n * float64(unit) / float64(time.Millisecond), nil
+	}
+
+	return 0, fmt.Errorf("should be a number or datetime, but got %s", field.Type().ItemTypeString())
+}
+
+func renamedField(field *data.Field, name string) *data.Field {
+	field.Name = name
+	return field
+}
@@ -6,18 +6,226 @@ type QueryModel struct {
 	QueryText          string
 	Limit              int64
 	DatetimeAttributes []DatetimeAttribute
+	// Maximum depth of nested maps to expand into "parent.child" attributes. 0 disables flattening
+	FlattenDepth int
+	// How lists are handled when flattening, ListFlattenModeJSON (default) or ListFlattenModeIndex
+	FlattenListMode string
+	// Attributes computed from path expressions on nested values
+	ExtractedAttributes []ExtractedAttribute
+	// Composite key attributes split into one attribute per part
+	CompositeAttributes []CompositeAttribute
+	// List attribute whose elements are exploded into separate rows
+	UnnestAttribute string
+	// Decoders of B and BS attributes. Binary values are base64 encoded by default
+	BinaryAttributes []BinaryAttribute
+	// Precision mode of number attributes
+	NumberPrecision string
+	// Precision mode of specific number attributes, e.g. IDs
+	NumberPrecisionAttributes []NumberPrecisionAttribute
+	// Type overrides of attributes, e.g. numbers stored as S
+	TypedAttributes []TypedAttribute
+	// Promote S attributes to numbers when all their values are numeric
+	InferTypes bool
+	// Datetime attribute restricted to the time range of the query
+	TimeFilterAttribute string
+	// Datetime attribute the frame is sorted by and used as the time of a time series
+	PrimaryTimeAttribute string
+	// Shape of the returned frame, one of the OutputMode constants. Defaults to OutputModeTable
+	OutputMode string
+	// Numeric mode: number attribute of the frame
+	ValueAttribute string
+	// Numeric mode: attributes identifying the series of a value
+	DimensionAttributes []string
+	// Numeric mode: NumericFormatLong (default) or NumericFormatWide
+	NumericFormat string
+	// Logs mode: attribute of the log line. The whole item is rendered as JSON if empty
+	BodyAttribute string
+	// Logs mode: attribute of the log level
+	SeverityAttribute string
+	// Logs mode: attributes added as labels of the log line
+	LabelAttributes []string
+	// Trace mode: attributes of the span fields
+	TraceMapping TraceMapping
+	// Node graph mode: attributes of the nodes and edges
+	NodeGraphMapping NodeGraphMapping
+	// Returns one frame per entity type of a single-table design
+	EntityDiscriminator EntityDiscriminator
+	// Region the query is executed in instead of the region of the datasource
+	Region string
+	// Role assumed for the query instead of the role of the datasource
+	AssumeRoleARN string
+	// Regions the query is executed in at the same time, instead of Region
+	Regions []string
+	// How the results of several regions are returned, one of the RegionOutput constants
+	RegionOutput string
+}
+
+type EntityDiscriminator struct {
+	// Attribute the entity type is read from, e.g. "type" or "SK"
+	Attribute string
+	// The entity type is the part of the value before the separator, e.g. "ORDER" in "ORDER#123" with "#"
+	Separator string
+	// Regular expression whose first capture group, or whole match, is the entity type. Takes precedence over Separator
+	Pattern string
+}
+
+type TraceMapping struct {
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	ServiceName   string
+	OperationName string
+	// Datetime attribute, or number attribute in Unix timestamp(ms)
+	StartTime string
+	Duration  string
+	// Unit of the duration, "s", "ms" (default), "us" or "ns"
+	DurationUnit string
+	// Attributes added as span tags. All other attributes if empty
+	Tags []string
+}
+
+type NodeGraphMapping struct {
+	NodeID            string
+	NodeTitle         string
+	NodeMainStat      string
+	NodeSecondaryStat string
+	EdgeSource        string
+	EdgeTarget        string
+	// Prefix of the edge target of edge items, e.g. "EDGE#". If empty, all items with edge attributes are edges
+	EdgePrefix string
+	// Prefixes removed from node IDs, e.g. "NODE#" and "EDGE#"
+	TrimPrefixes []string
 }
 
 type DatetimeAttribute struct {
 	Name   string
 	Format string
+	// Formats tried in order when a value can't be parsed with Format
+	Fallbacks []string
+	// IANA time zone of timestamps without offset, e.g. "Europe/Stockholm". Defaults to UTC
+	Timezone string
+}
+
+type ExtractedAttribute struct {
+	Name string
+	// Path to the nested value, e.g. "stats.host.cpu" or "meta.events[0].at"
+	Path string
+	// Optional type the value is converted to, one of the AttributeType constants
+	Type string
+	// Optional datetime format of the value
+	Format string
+}
+
+type CompositeAttribute struct {
+	// S attribute holding the composite key, e.g. "SK"
+	Name string
+	// Key template, e.g. "ORDER#{createdAt:iso}#{orderId}". A placeholder is "{name}" or "{name:format}",
+	// where format is one of the AttributeType constants, "iso" or a datetime format
+	Template string
+	// Regular expression whose named capture groups are the parts, used if Template is empty
+	Pattern string
+}
+
+type TypedAttribute struct {
+	Name string
+	Type string
 }
 
+// Types an attribute can be converted to
+const (
+	AttributeTypeString = "string"
+	// Integer or float, depending on the values
+	AttributeTypeNumber = "number"
+	AttributeTypeInt    = "int"
+	AttributeTypeFloat  = "float"
+	AttributeTypeBool   = "bool"
+	AttributeTypeTime   = "time"
+	AttributeTypeJSON   = "json"
+)
+
+type BinaryAttribute struct {
+	Name     string
+	Encoding string
+}
+
+const (
+	BinaryEncodingBase64 = "base64"
+	BinaryEncodingHex    = "hex"
+	BinaryEncodingText   = "utf8"
+	// Decompress with gzip and parse as JSON
+	BinaryEncodingGzip = "gzip"
+	// Decompress with zlib and parse as JSON
+	BinaryEncodingZlib = "zlib"
+)
+
+type NumberPrecisionAttribute struct {
+	Name      string
+	Precision string
+}
+
+const (
+	// Parse as int64 if possible, otherwise float64
+	NumberPrecisionAuto = ""
+	// Keep the exact decimal string
+	NumberPrecisionString = "string"
+	// Always parse as float64
+	NumberPrecisionFloat = "float"
+	// Parse as int64 or float64 and fail on precision loss
+	NumberPrecisionStrict = "strict"
+)
+
 var (
 	UnixTimestampSeconds     = "1"
 	UnixTimestampMiniseconds = "2"
 )
 
+// Named datetime formats. Any other format is a Go layout
+const (
+	// Unix timestamp in seconds, integer or decimal
+	DatetimeFormatUnixSeconds      = "unix_s"
+	DatetimeFormatUnixMilliseconds = "unix_ms"
+	DatetimeFormatUnixMicroseconds = "unix_us"
+	DatetimeFormatUnixNanoseconds  = "unix_ns"
+	// Date as S or N, e.g. 20241031
+	DatetimeFormatYYYYMMDD = "yyyymmdd"
+	// ISO-8601/RFC3339 strings with optional fractional seconds and offset, and Unix timestamps
+	// whose unit is guessed from the number of digits
+	DatetimeFormatAuto = "auto"
+)
+
+const (
+	OutputModeTable = "table"
+	// Dataplane numeric frame for alerting and server-side expressions
+	OutputModeNumeric = "numeric"
+	// Dataplane log lines frame for the Logs panel and Explore
+	OutputModeLogs = "logs"
+	// Span frame for the trace view
+	OutputModeTrace = "trace"
+	// Nodes and edges frames for the Node Graph panel
+	OutputModeNodeGraph = "nodegraph"
+)
+
+const (
+	// One frame with the region of each item in the RegionAttribute column
+	RegionOutputColumn = "column"
+	// One frame per region, labelled with the region
+	RegionOutputSeries = "series"
+)
+
+const (
+	// Dimensions as string fields
+	NumericFormatLong = "long"
+	// Dimensions as labels of one field per item
+	NumericFormatWide = "wide"
+)
+
+const (
+	// Keep lists as JSON attributes
+	ListFlattenModeJSON = "json"
+	// Expand lists into "parent[0]", "parent[1]", ... attributes
+	ListFlattenModeIndex = "index"
+)
+
 type DynamoDBDataType int
 
 type DataRow map[string]*dynamodb.AttributeValue
@@ -38,4 +246,56 @@ const (
 
 type ExtraPluginSettings struct {
 	ConnectionTestTable string `json:"connectionTestTable"`
+	// Datetime attributes applied to every query, overridden by the query's own datetime attributes
+	DatetimeAttributes []DefaultDatetimeAttribute `json:"datetimeAttributes"`
+	// Handling of statements executed as full table scans, one of the ScanGuard constants
+	ScanGuard string `json:"scanGuard"`
+	// Tables that may be scanned regardless of the scan guard
+	ScanAllowedTables []string `json:"scanAllowedTables"`
+	// Maximum number of queries of a request executed at the same time. Defaults to defaultMaxConcurrentQueries
+	MaxConcurrentQueries int `json:"maxConcurrentQueries"`
+	// Timeout of each query as a Go duration, e.g. "30s". No timeout if empty
+	QueryTimeout string `json:"queryTimeout"`
+	// Regions queries may override the region of the datasource with
+	AllowedRegions []string `json:"allowedRegions"`
+	// Roles queries may assume instead of the role of the datasource
+	AllowedAssumeRoleARNs []string `json:"allowedAssumeRoleArns"`
+	// Mandatory predicates added to the statements of matching users
+	AccessRules []AccessRule `json:"accessRules"`
+	// Writes an audit record of every query
+	AuditLog bool `json:"auditLog"`
+	// Level of the audit records in the plugin log, "debug", "info" (default) or "warn"
+	AuditLogLevel string `json:"auditLogLevel"`
+	// Optional file the audit records are appended to as JSON lines
+	AuditLogFile string `json:"auditLogFile"`
+	// Top-level attributes whose values are replaced by "?" in the statements of audit records. "*" redacts all values
+	AuditSensitiveAttributes []string `json:"auditSensitiveAttributes"`
+}
+
+type AccessRule struct {
+	// Table the rule applies to. Empty for all tables
+	Table string `json:"table"`
+	// Logins or emails of the users the rule applies to
+	Users []string `json:"users"`
+	// Organization roles the rule applies to, e.g. "Viewer". A rule without users and roles applies to everyone
+	Roles []string `json:"roles"`
+	// PartiQL condition, e.g. "tenantId = 'acme'"
+	Predicate string `json:"predicate"`
+}
+
+const defaultMaxConcurrentQueries = 10
+
+const (
+	// Scans are executed without checks
+	ScanGuardOff = ""
+	// Scans are executed and the frames get a warning notice
+	ScanGuardWarn = "warn"
+	// Scans are rejected
+	ScanGuardBlock = "block"
+)
+
+type DefaultDatetimeAttribute struct {
+	// Table the mapping applies to. Empty for all tables
+	Table string `json:"table"`
+	DatetimeAttribute
 }
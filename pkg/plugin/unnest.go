@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// UnnestItems turns every element of the list attribute into its own row. The other attributes
+// of the item are copied onto each row, and map elements are flattened into "name.key" attributes.
+// Items without the list attribute are kept as they are.
+func UnnestItems(items []map[string]*dynamodb.AttributeValue, name string) []map[string]*dynamodb.AttributeValue {
+	var unnested []map[string]*dynamodb.AttributeValue
+
+	for _, item := range items {
+		list, ok := item[name]
+		if !ok || list.L == nil {
+			unnested = append(unnested, item)
+			continue
+		}
+
+		parent := make(map[string]*dynamodb.AttributeValue, len(item))
+		for k, v := range item {
+			if k != name {
+				parent[k] = v
+			}
+		}
+
+		if len(list.L) == 0 {
+			unnested = append(unnested, parent)
+			continue
+		}
+
+		for _, element := range list.L {
+			row := make(map[string]*dynamodb.AttributeValue, len(parent)+1)
+			for k, v := range parent {
+				row[k] = v
+			}
+			flattenAttribute(row, name, element, 1, ListFlattenModeJSON)
+			unnested = append(unnested, row)
+		}
+	}
+
+	return unnested
+}
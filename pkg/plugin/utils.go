@@ -2,9 +2,12 @@ package plugin
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -21,6 +24,13 @@ func loadExtraPluginSettings(source backend.DataSourceInstanceSettings) (*ExtraP
 		return nil, fmt.Errorf("could not unmarshal PluginSettings json: %w", err)
 	}
 
+	if settings.QueryTimeout != "" {
+		timeout, err := time.ParseDuration(settings.QueryTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid query timeout %s", settings.QueryTimeout)
+		}
+	}
+
 	return &settings, nil
 }
 
@@ -59,6 +69,52 @@ func parseNumber(n string) (*int64, *float64, error) {
 	return nil, nil, fmt.Errorf("failed to parse %s", n)
 }
 
+// parseNumberWithPrecision parses a number like parseNumber, but always returns a float64 in float mode
+// and fails in strict mode if the number can't be represented without precision loss
+func parseNumberWithPrecision(n string, precision string) (*int64, *float64, error) {
+	switch precision {
+	case NumberPrecisionAuto, NumberPrecisionString:
+		return parseNumber(n)
+	case NumberPrecisionFloat:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s", n)
+		}
+		return nil, aws.Float64(f), nil
+	case NumberPrecisionStrict:
+		i, f, err := parseNumber(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		if f != nil && !isLosslessFloat(n, *f) {
+			return nil, nil, fmt.Errorf("number %s can't be represented as float64 without precision loss", n)
+		}
+		return i, f, nil
+	}
+
+	return nil, nil, fmt.Errorf("invalid number precision %s", precision)
+}
+
+// isLosslessFloat reports whether the shortest decimal representation of f has the same value as n,
+// i.e. no digits of n are lost when it's parsed as float64
+func isLosslessFloat(n string, f float64) bool {
+	exact, ok := new(big.Rat).SetString(n)
+	if !ok {
+		return false
+	}
+	shortest, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return ok && exact.Cmp(shortest) == 0
+}
+
+// int64ToFloat64 converts an integer when a column is widened to float64. In strict mode it fails
+// if the integer is larger than the integers float64 represents exactly
+func int64ToFloat64(i int64, precision string) (*float64, error) {
+	if precision == NumberPrecisionStrict && (i > 1<<53 || i < -(1<<53)) {
+		return nil, fmt.Errorf("number %d can't be represented as float64 without precision loss", i)
+	}
+	return aws.Float64(float64(i)), nil
+}
+
 func PrintDataFrame(dataFrame *data.Frame) {
 	// Print headers
 	fmt.Print("|")
@@ -144,11 +200,29 @@ func stringSetToJson(value *dynamodb.AttributeValue) (*json.RawMessage, error) {
 	return Pointer(json.RawMessage(jsonString)), nil
 }
 
-func numberSetToJson(value *dynamodb.AttributeValue) (*json.RawMessage, error) {
+func binarySetToJson(value *dynamodb.AttributeValue) (*json.RawMessage, error) {
+	l := make([]string, len(value.BS))
+	for i, b := range value.BS {
+		l[i] = base64.StdEncoding.EncodeToString(b)
+	}
+
+	jsonString, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return Pointer(json.RawMessage(jsonString)), nil
+}
+
+func numberSetToJson(value *dynamodb.AttributeValue, precision string) (*json.RawMessage, error) {
 	l := make([]interface{}, len(value.NS))
 
 	for idx, n := range value.NS {
-		i, f, err := parseNumber(*n)
+		if precision == NumberPrecisionString {
+			l[idx] = *n
+			continue
+		}
+
+		i, f, err := parseNumberWithPrecision(*n, precision)
 		if err != nil {
 			return nil, err
 		}
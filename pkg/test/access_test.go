@@ -0,0 +1,65 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestApplyAccessRules(t *testing.T) {
+	rules := []plugin.AccessRule{
+		{Table: "Orders", Roles: []string{"Viewer"}, Predicate: "tenantId = 'acme'"},
+		{Table: "Orders", Users: []string{"bob"}, Predicate: "region = 'eu' OR region = 'us'"},
+		{Table: "Audit", Predicate: "public = true"},
+	}
+	viewer := &backend.User{Login: "alice", Role: "Viewer"}
+	bob := &backend.User{Login: "bob", Email: "bob@example.com", Role: "Viewer"}
+	admin := &backend.User{Login: "admin", Role: "Admin"}
+
+	tests := []struct {
+		name      string
+		statement string
+		user      *backend.User
+		expected  string
+	}{
+		{"no condition", `SELECT * FROM Orders`, viewer, `SELECT * FROM Orders WHERE (tenantId = 'acme')`},
+		{"existing condition", `SELECT * FROM "Orders" WHERE tenantId = 'other' OR 1 = 1`, viewer, `SELECT * FROM "Orders" WHERE (tenantId = 'other' OR 1 = 1) AND (tenantId = 'acme')`},
+		{"several rules", `SELECT * FROM Orders ORDER BY ts`, bob, `SELECT * FROM Orders WHERE (tenantId = 'acme') AND (region = 'eu' OR region = 'us') ORDER BY ts`},
+		{"no matching rule", `SELECT * FROM Orders`, admin, `SELECT * FROM Orders`},
+		{"rule for everyone", `SELECT * FROM Audit`, admin, `SELECT * FROM Audit WHERE (public = true)`},
+		{"anonymous with rule for everyone", `SELECT * FROM Audit`, nil, `SELECT * FROM Audit WHERE (public = true)`},
+		{"quoted FROM", `SELECT "x from Other" FROM Orders`, viewer, `SELECT "x from Other" FROM Orders WHERE (tenantId = 'acme')`},
+		{"no whitespace", `SELECT * FROM"Orders"`, viewer, `SELECT * FROM"Orders" WHERE (tenantId = 'acme')`},
+		{"comment in literal", `SELECT * FROM Orders WHERE note = '--'`, viewer, `SELECT * FROM Orders WHERE (note = '--') AND (tenantId = 'acme')`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statement, err := plugin.ApplyAccessRules(test.statement, rules, test.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertEqual(t, statement, test.expected)
+		})
+	}
+
+	for _, test := range []struct {
+		statement string
+		user      *backend.User
+	}{
+		{`SELECT * FROM Orders --`, viewer},
+		{`SELECT * FROM Orders /* WHERE */`, viewer},
+		{`DELETE FROM Orders WHERE id = 1`, viewer},
+		{`SELECT * FROM Orders WHERE a = 1 WHERE b = 2`, viewer},
+		{`SELECT * FROM 'Orders'`, admin},
+		{`SELECT * FROM Orders`, nil},
+		{`SELECT * FROM Orders WHERE a = 1) OR (1 = 1`, viewer},
+		{`SELECT * FROM Orders WHERE a IN ['x']) OR (1=1`, viewer},
+	} {
+		_, err := plugin.ApplyAccessRules(test.statement, rules, test.user)
+		if err == nil {
+			t.Errorf("%s: expected error", test.statement)
+		}
+	}
+}
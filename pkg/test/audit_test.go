@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestRedactStatement(t *testing.T) {
+	sensitive := []string{"email", "ssn"}
+	tests := []struct {
+		statement string
+		expected  string
+	}{
+		{`SELECT * FROM Orders WHERE email = 'bob@example.com' AND total > 10.5`, `SELECT * FROM Orders WHERE email = ? AND total > 10.5`},
+		{`SELECT * FROM Orders WHERE (tenantId = 'acme') AND ("email" IN ['a', 'b'] OR 'c' = email)`, `SELECT * FROM Orders WHERE (tenantId = 'acme') AND ("email" IN [?, ?] OR ? = email)`},
+		{`SELECT * FROM Orders WHERE begins_with(ssn, '123') AND ts BETWEEN 1730238174 AND 1730324262`, `SELECT * FROM Orders WHERE begins_with(ssn, ?) AND ts BETWEEN 1730238174 AND 1730324262`},
+		{`SELECT * FROM Orders WHERE email.domain <> 'example.com' AND events[0].at > 5 AND note = 'it''s'`, `SELECT * FROM Orders WHERE email.domain <> ? AND events[0].at > 5 AND note = 'it''s'`},
+		{`SELECT * FROM Orders WHERE email IS NOT MISSING AND email > 'a'`, `SELECT * FROM Orders WHERE email IS NOT MISSING AND email > ?`},
+		{`INSERT INTO Users VALUE {'id': 1, 'ssn': '123-45-6789'}`, `INSERT INTO Users VALUE {'id': 1, 'ssn': ?}`},
+		{`SELECT * FROM Orders WHERE 5 > 3`, `SELECT * FROM Orders WHERE ? > ?`},
+	}
+
+	for _, test := range tests {
+		assertEqual(t, plugin.RedactStatement(test.statement, sensitive), test.expected)
+	}
+
+	statement := `SELECT "col1" FROM "Orders2"."byDate" WHERE note = 'it''s' AND id IN [1, 2]`
+	assertEqual(t, plugin.RedactStatement(statement, nil), statement)
+	assertEqual(t, plugin.RedactStatement(statement, []string{"*"}), `SELECT "col1" FROM "Orders2"."byDate" WHERE note = ? AND id IN [?, ?]`)
+}
+
+func TestAuditLog(t *testing.T) {
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"1"}},{"id":{"N":"2"}}],"ConsumedCapacity":{"TableName":"Orders","CapacityUnits":2.5}}`))
+	})
+
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	ds.ExtraSettings = plugin.ExtraPluginSettings{AuditLog: true, AuditLogFile: file}
+
+	qm, err := json.Marshal(plugin.QueryModel{QueryText: "SELECT * FROM Orders WHERE tenantId = 'acme'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{RefID: "A", JSON: qm}},
+		PluginContext: backend.PluginContext{
+			OrgID:                      2,
+			User:                       &backend.User{Login: "alice"},
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds1"},
+			GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+	}
+	req.SetHTTPHeader("X-Dashboard-Uid", "dash1")
+	req.SetHTTPHeader("X-Panel-Id", "4")
+
+	resp, err := ds.QueryData(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Responses["A"].Error != nil {
+		t.Fatal(resp.Responses["A"].Error)
+	}
+	ds.Dispose()
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assertEqual(t, len(lines), 1)
+
+	var record plugin.AuditRecord
+	err = json.Unmarshal([]byte(lines[0]), &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, record.User, "alice")
+	assertEqual(t, record.OrgID, int64(2))
+	assertEqual(t, record.DatasourceUID, "ds1")
+	assertEqual(t, record.DashboardUID, "dash1")
+	assertEqual(t, record.PanelID, "4")
+	assertEqual(t, record.Statement, "SELECT * FROM Orders WHERE tenantId = 'acme'")
+	assertEqual(t, record.Table, "Orders")
+	assertEqual(t, record.ConsumedCapacity, 2.5)
+	assertEqual(t, record.RowCount, 2)
+	assertEqual(t, record.ErrorCode, "")
+}
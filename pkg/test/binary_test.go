@@ -0,0 +1,93 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return compressed.Bytes()
+}
+
+func TestDecodeBinaryAttributes(t *testing.T) {
+	compressed := gzipBytes(t, []byte(`{"cpu":0.5,"host":"a"}`))
+
+	items := []map[string]*dynamodb.AttributeValue{
+		{
+			"myB":    {B: []byte("hello")},
+			"myHex":  {B: []byte{0xde, 0xad}},
+			"myText": {B: []byte("hello")},
+			"myGzip": {B: compressed},
+			"myBS":   {BS: [][]byte{[]byte("a"), []byte("b")}},
+		},
+	}
+
+	err := plugin.DecodeBinaryAttributes(items, []plugin.BinaryAttribute{
+		{Name: "myHex", Encoding: plugin.BinaryEncodingHex},
+		{Name: "myText", Encoding: plugin.BinaryEncodingText},
+		{Name: "myGzip", Encoding: plugin.BinaryEncodingGzip},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("test", &dynamodb.ExecuteStatementOutput{Items: items}, plugin.DataFrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := frame.FieldByName("myB")
+	assertEqual(t, b.At(0), aws.String("aGVsbG8="))
+
+	h, _ := frame.FieldByName("myHex")
+	assertEqual(t, h.At(0), aws.String("dead"))
+
+	text, _ := frame.FieldByName("myText")
+	assertEqual(t, text.At(0), aws.String("hello"))
+
+	g, _ := frame.FieldByName("myGzip")
+	assertEqual(t, g.Type(), data.FieldTypeNullableJSON)
+	assertEqual(t, g.At(0), plugin.Pointer(json.RawMessage(`{"cpu":0.5,"host":"a"}`)))
+
+	bs, _ := frame.FieldByName("myBS")
+	assertEqual(t, bs.At(0), plugin.Pointer(json.RawMessage(`["YQ==","Yg=="]`)))
+}
+
+func TestDecodeCompressedJson(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"payload": {B: gzipBytes(t, []byte(`{"id":12345678901234567891,"tags":["a",null,true]}`))}},
+	}
+
+	err := plugin.DecodeBinaryAttributes(items, []plugin.BinaryAttribute{{Name: "payload", Encoding: plugin.BinaryEncodingGzip}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := items[0]["payload"].M
+	assertEqual(t, *payload["id"].N, "12345678901234567891")
+	assertEqual(t, *payload["tags"].L[0].S, "a")
+	assertEqual(t, *payload["tags"].L[1].NULL, true)
+	assertEqual(t, *payload["tags"].L[2].BOOL, true)
+
+	// Decompresses to more than the limit of 10 MiB
+	bomb := append(bytes.Repeat([]byte(" "), 11<<20), '1')
+	items = []map[string]*dynamodb.AttributeValue{{"payload": {B: gzipBytes(t, bomb)}}}
+	err = plugin.DecodeBinaryAttributes(items, []plugin.BinaryAttribute{{Name: "payload", Encoding: plugin.BinaryEncodingGzip}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+// BenchmarkQueryData compares panels refreshing against a datasource instance that reuses its clients
+// with panels that create new clients for every request
+func BenchmarkQueryData(b *testing.B) {
+	ds := newFakeDatasource(b, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"1"},"name":{"S":"a"}},{"id":{"N":"2"},"name":{"S":"b"}}]}`))
+	})
+
+	qm, err := json.Marshal(plugin.QueryModel{QueryText: "SELECT * FROM test WHERE id = 1"})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{RefID: "A", JSON: qm}},
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+			GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+	}
+
+	query := func(b *testing.B, ds *plugin.Datasource) {
+		resp, err := ds.QueryData(context.Background(), req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if resp.Responses["A"].Error != nil {
+			b.Fatal(resp.Responses["A"].Error)
+		}
+	}
+
+	b.Run("reused clients", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				query(b, ds)
+			}
+		})
+	})
+
+	b.Run("new clients", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				newDs := plugin.CreateTestDatasource(context.Background())
+				newDs.Settings.Endpoint = ds.Settings.Endpoint
+				query(b, newDs)
+				newDs.Dispose()
+			}
+		})
+	})
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestParseCompositeAttributes(t *testing.T) {
+	t.Run("template", func(t *testing.T) {
+		items := []map[string]*dynamodb.AttributeValue{
+			{"SK": {S: aws.String("ORDER#2024-10-31T08:00:00Z#42")}},
+			{"SK": {S: aws.String("PROFILE")}},
+		}
+
+		err := plugin.ParseCompositeAttributes(items, []plugin.CompositeAttribute{
+			{Name: "SK", Template: "ORDER#{createdAt:iso}#{orderId:int}"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, *items[0]["createdAt"].S, "2024-10-31T08:00:00Z")
+		assertEqual(t, *items[0]["orderId"].S, "42")
+		if _, ok := items[1]["orderId"]; ok {
+			t.Fatal("unexpected part of a value not matching the template")
+		}
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		items := []map[string]*dynamodb.AttributeValue{
+			{"PK": {S: aws.String("TENANT#acme|USER#7")}},
+		}
+
+		err := plugin.ParseCompositeAttributes(items, []plugin.CompositeAttribute{
+			{Name: "PK", Pattern: `^TENANT#(?P<tenant>[^|]+)\|(USER)#(?P<user>\d+)$`},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, *items[0]["tenant"].S, "acme")
+		assertEqual(t, *items[0]["user"].S, "7")
+		assertEqual(t, len(items[0]), 3)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		err := plugin.ParseCompositeAttributes(nil, []plugin.CompositeAttribute{{Name: "SK", Template: "ORDER#"}})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestCompositeAttributesQuery(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"SK": {S: aws.String("ORDER#2024-10-31T08:00:00Z#42")}},
+	}
+
+	err := plugin.ParseCompositeAttributes(items, []plugin.CompositeAttribute{
+		{Name: "SK", Template: "ORDER#{createdAt:iso}#{orderId:int}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("test", &dynamodb.ExecuteStatementOutput{Items: items}, plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{"createdAt": plugin.DatetimeFormatAuto},
+		AttributeTypes:     map[string]string{"orderId": plugin.AttributeTypeInt},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createdAt, _ := frame.FieldByName("createdAt")
+	assertEqual(t, getFieldValue[time.Time](t, createdAt, 0), time.Date(2024, 10, 31, 8, 0, 0, 0, time.UTC))
+	orderId, _ := frame.FieldByName("orderId")
+	assertEqual(t, getFieldValue[int64](t, orderId, 0), int64(42))
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestQueryDataConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		var input struct{ Statement string }
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		delay := 50 * time.Millisecond
+		if strings.Contains(input.Statement, "slow") {
+			delay = 2 * time.Second
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"1"}}]}`))
+	})
+	ds.ExtraSettings = plugin.ExtraPluginSettings{MaxConcurrentQueries: 3, QueryTimeout: "500ms"}
+
+	var queries []backend.DataQuery
+	for i := 0; i < 8; i++ {
+		table := "fast"
+		if i == 0 {
+			table = "slow"
+		}
+		qm, err := json.Marshal(plugin.QueryModel{QueryText: "SELECT * FROM " + table})
+		if err != nil {
+			t.Fatal(err)
+		}
+		queries = append(queries, backend.DataQuery{RefID: fmt.Sprintf("Q%d", i), JSON: qm})
+	}
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: queries,
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+			GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, len(resp.Responses), 8)
+	assertEqual(t, resp.Responses["Q0"].Status, backend.StatusTimeout)
+	for i := 1; i < 8; i++ {
+		res := resp.Responses[fmt.Sprintf("Q%d", i)]
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		assertEqual(t, len(res.Frames), 1)
+	}
+	assertEqual(t, atomic.LoadInt32(&maxInFlight) <= 3, true)
+	assertEqual(t, atomic.LoadInt32(&maxInFlight) > 1, true)
+}
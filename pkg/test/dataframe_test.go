@@ -37,7 +37,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -68,7 +68,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -98,7 +98,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -128,7 +128,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -155,7 +155,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -194,7 +194,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -232,7 +232,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -256,7 +256,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -280,7 +280,7 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, make(map[string]string))
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -304,8 +304,8 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, map[string]string{
-			"myDate": plugin.UnixTimestampSeconds})
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			DatetimeAttributes: map[string]string{"myDate": plugin.UnixTimestampSeconds}})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -334,8 +334,8 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, map[string]string{
-			"myDate": plugin.UnixTimestampMiniseconds})
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			DatetimeAttributes: map[string]string{"myDate": plugin.UnixTimestampMiniseconds}})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -365,8 +365,8 @@ func TestQueryResultToDataFrame(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		frame, err := plugin.QueryResultToDataFrame("test", output, map[string]string{
-			"myDate": "2006-01-02T15:04:05.999Z"})
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			DatetimeAttributes: map[string]string{"myDate": "2006-01-02T15:04:05.999Z"}})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestDatetimeFormats(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    *dynamodb.AttributeValue
+		format   string
+		expected int64
+	}{
+		{"legacy seconds", &dynamodb.AttributeValue{N: aws.String("1731017392")}, plugin.UnixTimestampSeconds, 1731017392000000000},
+		{"legacy milliseconds", &dynamodb.AttributeValue{N: aws.String("1731017392123")}, plugin.UnixTimestampMiniseconds, 1731017392123000000},
+		{"float seconds", &dynamodb.AttributeValue{N: aws.String("1731017392.123")}, plugin.DatetimeFormatUnixSeconds, 1731017392123000000},
+		{"microseconds", &dynamodb.AttributeValue{N: aws.String("1731017392123456")}, plugin.DatetimeFormatUnixMicroseconds, 1731017392123456000},
+		{"nanoseconds", &dynamodb.AttributeValue{N: aws.String("1731017392123456789")}, plugin.DatetimeFormatUnixNanoseconds, 1731017392123456789},
+		{"seconds as string", &dynamodb.AttributeValue{S: aws.String("1731017392")}, plugin.DatetimeFormatUnixSeconds, 1731017392000000000},
+		{"yyyymmdd", &dynamodb.AttributeValue{N: aws.String("20241031")}, plugin.DatetimeFormatYYYYMMDD, 1730332800000000000},
+		{"auto RFC3339", &dynamodb.AttributeValue{S: aws.String("2024-10-31T22:04:29+01:00")}, plugin.DatetimeFormatAuto, 1730408669000000000},
+		{"auto fractional", &dynamodb.AttributeValue{S: aws.String("2024-10-31T21:04:29.123Z")}, plugin.DatetimeFormatAuto, 1730408669123000000},
+		{"auto naive", &dynamodb.AttributeValue{S: aws.String("2023-08-07T22:18:48.790770")}, plugin.DatetimeFormatAuto, 1691446728790770000},
+		{"auto milliseconds", &dynamodb.AttributeValue{N: aws.String("1731017392123")}, plugin.DatetimeFormatAuto, 1731017392123000000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			output := &dynamodb.ExecuteStatementOutput{
+				Items: []map[string]*dynamodb.AttributeValue{{"ts": c.value}},
+			}
+
+			frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+				DatetimeAttributes: map[string]string{"ts": c.format},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			field := frame.Fields[0]
+			assertEqual(t, field.Type(), data.FieldTypeNullableTime)
+			assertEqual(t, getFieldValue[time.Time](t, field, 0).UnixNano(), c.expected)
+		})
+	}
+
+	t.Run("fallbacks and timezone", func(t *testing.T) {
+		output := &dynamodb.ExecuteStatementOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"ts": {S: aws.String("2024-10-31T22:04:29+01:00")}},
+				{"ts": {S: aws.String("2024-10-31 22:04:29")}},
+			},
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			DatetimeAttributes: map[string]string{"ts": time.RFC3339},
+			DatetimeFallbacks:  map[string][]string{"ts": {"2006-01-02 15:04:05"}},
+			DatetimeTimezones:  map[string]string{"ts": "Europe/Stockholm"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		field := frame.Fields[0]
+		assertEqual(t, getFieldValue[time.Time](t, field, 0).Unix(), int64(1730408669))
+		assertEqual(t, getFieldValue[time.Time](t, field, 1).Unix(), int64(1730408669))
+	})
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestDefaultDatetimeAttributes(t *testing.T) {
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		var input struct{ Statement string }
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if strings.Contains(input.Statement, "Dates") {
+			_, _ = w.Write([]byte(`{"Items":[{"ts":{"S":"2024-10-31"}}]}`))
+		} else {
+			_, _ = w.Write([]byte(`{"Items":[{"ts":{"N":"1730000000"}}]}`))
+		}
+	})
+	ds.ExtraSettings = plugin.ExtraPluginSettings{
+		DatetimeAttributes: []plugin.DefaultDatetimeAttribute{
+			{Table: "Orders", DatetimeAttribute: plugin.DatetimeAttribute{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds}},
+			{DatetimeAttribute: plugin.DatetimeAttribute{Name: "ts", Format: plugin.DatetimeFormatUnixMilliseconds, Fallbacks: []string{plugin.DatetimeFormatAuto}, Timezone: "Asia/Tokyo"}},
+		},
+	}
+
+	query := func(qm plugin.QueryModel) backend.DataResponse {
+		rawJson, err := json.Marshal(qm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: rawJson}},
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses["A"]
+	}
+
+	ts := func(res backend.DataResponse) time.Time {
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		field, _ := res.Frames[0].FieldByName("ts")
+		return getFieldValue[time.Time](t, field, 0)
+	}
+
+	t.Run("table default overrides global default", func(t *testing.T) {
+		assertEqual(t, ts(query(plugin.QueryModel{QueryText: "SELECT * FROM Orders"})), time.Unix(1730000000, 0).UTC())
+	})
+
+	t.Run("global default", func(t *testing.T) {
+		assertEqual(t, ts(query(plugin.QueryModel{QueryText: "SELECT * FROM Other"})), time.UnixMilli(1730000000).UTC())
+	})
+
+	t.Run("query attribute overrides defaults", func(t *testing.T) {
+		res := query(plugin.QueryModel{
+			QueryText:          "SELECT * FROM Dates",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: "2006-01-02"}},
+		})
+		// The time zone of the global default doesn't apply
+		assertEqual(t, ts(res), time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("query attribute doesn't inherit fallbacks", func(t *testing.T) {
+		res := query(plugin.QueryModel{
+			QueryText:          "SELECT * FROM Other",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: "2006-01-02"}},
+		})
+		assertEqual(t, res.Error != nil, true)
+	})
+}
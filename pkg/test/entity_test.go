@@ -0,0 +1,69 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestSplitItemsByEntity(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"PK": {S: aws.String("CUSTOMER#1")}, "SK": {S: aws.String("ORDER#2024-01-01")}, "total": {N: aws.String("10")}},
+		{"PK": {S: aws.String("CUSTOMER#1")}, "SK": {S: aws.String("PROFILE")}, "name": {S: aws.String("Alice")}},
+		{"PK": {S: aws.String("CUSTOMER#1")}, "SK": {S: aws.String("ORDER#2024-01-02")}, "total": {N: aws.String("20")}},
+		{"PK": {S: aws.String("CUSTOMER#1")}, "type": {S: aws.String("note")}},
+	}
+
+	t.Run("separator", func(t *testing.T) {
+		entities, err := plugin.SplitItemsByEntity(items, plugin.EntityDiscriminator{Attribute: "SK", Separator: "#"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, len(entities), 2)
+		assertEqual(t, entities[0].Name, "ORDER")
+		assertEqual(t, len(entities[0].Items), 2)
+		assertEqual(t, entities[1].Name, "")
+		assertEqual(t, len(entities[1].Items), 2)
+
+		frame, err := plugin.QueryResultToDataFrame(entities[0].Name, &dynamodb.ExecuteStatementOutput{Items: entities[0].Items}, plugin.DataFrameOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, frame.Name, "ORDER")
+		assertEqual(t, len(frame.Fields), 3)
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		entities, err := plugin.SplitItemsByEntity(items, plugin.EntityDiscriminator{Attribute: "SK", Pattern: "^([A-Z]+)"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, len(entities), 3)
+		assertEqual(t, entities[0].Name, "ORDER")
+		assertEqual(t, entities[1].Name, "PROFILE")
+		assertEqual(t, entities[2].Name, "")
+	})
+
+	t.Run("attribute", func(t *testing.T) {
+		entities, err := plugin.SplitItemsByEntity(items, plugin.EntityDiscriminator{Attribute: "type"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, len(entities), 2)
+		assertEqual(t, entities[0].Name, "")
+		assertEqual(t, len(entities[0].Items), 3)
+		assertEqual(t, entities[1].Name, "note")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := plugin.SplitItemsByEntity(items, plugin.EntityDiscriminator{Attribute: "SK", Pattern: "("})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
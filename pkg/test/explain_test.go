@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	ds := plugin.CreateTestDatasource(ctx)
+
+	err := createTable(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explain := func(statement string) (*backend.CallResourceResponse, plugin.ExplainResponse) {
+		body, err := json.Marshal(plugin.ExplainRequest{Query: plugin.QueryModel{QueryText: statement}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var resp *backend.CallResourceResponse
+		err = ds.CallResource(ctx, &backend.CallResourceRequest{
+			Path:   "explain",
+			Method: http.MethodPost,
+			Body:   body,
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		}, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+			resp = r
+			return nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var explanation plugin.ExplainResponse
+		if resp.Status == http.StatusOK {
+			err = json.Unmarshal(resp.Body, &explanation)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp, explanation
+	}
+
+	resp, explanation := explain("SELECT * FROM test WHERE id = 1")
+	assertEqual(t, resp.Status, http.StatusOK)
+	assertEqual(t, explanation.Table, "test")
+	assertEqual(t, explanation.Operation, plugin.OperationQuery)
+	assertEqual(t, explanation.PartitionKey, "id")
+
+	resp, explanation = explain("SELECT * FROM test WHERE sid = 1")
+	assertEqual(t, resp.Status, http.StatusOK)
+	assertEqual(t, explanation.Operation, plugin.OperationScan)
+
+	resp, _ = explain("SELECT * FROM missing")
+	assertEqual(t, resp.Status, http.StatusBadRequest)
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestExtractAttributes(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{
+			"stats": {M: map[string]*dynamodb.AttributeValue{
+				"host": {M: map[string]*dynamodb.AttributeValue{
+					"cpu": {S: aws.String("0.75")},
+				}},
+			}},
+			"meta": {M: map[string]*dynamodb.AttributeValue{
+				"events": {L: []*dynamodb.AttributeValue{
+					{M: map[string]*dynamodb.AttributeValue{
+						"at": {N: aws.String("1730070554000")},
+					}},
+				}},
+			}},
+		},
+		{
+			"stats": {M: map[string]*dynamodb.AttributeValue{}},
+		},
+	}
+
+	err := plugin.ExtractAttributes(items, []plugin.ExtractedAttribute{
+		{Name: "cpu", Path: "stats.host.cpu", Type: plugin.AttributeTypeNumber},
+		{Name: "ts", Path: "meta.events[0].at", Format: plugin.UnixTimestampMiniseconds},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("test", &dynamodb.ExecuteStatementOutput{Items: items}, plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{
+			"ts": plugin.UnixTimestampMiniseconds,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var null *float64
+	cpu, _ := frame.FieldByName("cpu")
+	assertEqual(t, cpu.Type(), data.FieldTypeNullableFloat64)
+	assertEqual(t, cpu.At(0), plugin.Pointer(0.75))
+	assertEqual(t, cpu.At(1), null)
+
+	ts, _ := frame.FieldByName("ts")
+	assertEqual(t, ts.Type(), data.FieldTypeNullableTime)
+	assertEqual(t, getFieldValue[time.Time](t, ts, 0).UnixMilli(), int64(1730070554000))
+
+	t.Run("invalid path", func(t *testing.T) {
+		err := plugin.ExtractAttributes(items, []plugin.ExtractedAttribute{
+			{Name: "x", Path: "meta.events[a]"},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
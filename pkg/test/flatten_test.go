@@ -0,0 +1,67 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestFlattenItems(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{
+			"metrics": {M: map[string]*dynamodb.AttributeValue{
+				"cpu": {N: aws.String("0.5")},
+				"host": {M: map[string]*dynamodb.AttributeValue{
+					"name": {S: aws.String("a")},
+				}},
+			}},
+			"tags": {L: []*dynamodb.AttributeValue{
+				{S: aws.String("t1")},
+				{S: aws.String("t2")},
+			}},
+		},
+	}
+
+	t.Run("depth 1", func(t *testing.T) {
+		output := &dynamodb.ExecuteStatementOutput{
+			Items: plugin.FlattenItems(items, 1, plugin.ListFlattenModeJSON),
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cpu, _ := frame.FieldByName("metrics.cpu")
+		assertEqual(t, cpu.Type(), data.FieldTypeNullableFloat64)
+		assertEqual(t, cpu.At(0), plugin.Pointer(0.5))
+
+		host, _ := frame.FieldByName("metrics.host")
+		assertEqual(t, host.Type(), data.FieldTypeNullableJSON)
+
+		tags, _ := frame.FieldByName("tags")
+		assertEqual(t, tags.Type(), data.FieldTypeNullableJSON)
+		assertEqual(t, tags.At(0), plugin.Pointer(json.RawMessage(`["t1","t2"]`)))
+	})
+
+	t.Run("depth 2 with list index", func(t *testing.T) {
+		output := &dynamodb.ExecuteStatementOutput{
+			Items: plugin.FlattenItems(items, 2, plugin.ListFlattenModeIndex),
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name, _ := frame.FieldByName("metrics.host.name")
+		assertEqual(t, name.At(0), plugin.Pointer("a"))
+
+		tag, _ := frame.FieldByName("tags[1]")
+		assertEqual(t, tag.At(0), plugin.Pointer("t2"))
+	})
+}
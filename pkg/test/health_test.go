@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestCheckHealth(t *testing.T) {
+	ctx := context.Background()
+	ds := plugin.CreateTestDatasource(ctx)
+
+	err := createTable(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkHealth := func(jsonData string) (*backend.CheckHealthResult, plugin.HealthDetails) {
+		res, err := ds.CheckHealth(ctx, &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{JSONData: []byte(jsonData)},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var details plugin.HealthDetails
+		err = json.Unmarshal(res.JSONDetails, &details)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res, details
+	}
+
+	t.Run("without test table", func(t *testing.T) {
+		res, details := checkHealth(`{}`)
+		assertEqual(t, res.Status, backend.HealthStatusOk)
+		assertEqual(t, details.Region, "us-east-1")
+		assertEqual(t, details.Checks[0].Name, "ListTables")
+		assertEqual(t, details.Table != "", true)
+	})
+
+	t.Run("with test table", func(t *testing.T) {
+		res, details := checkHealth(`{"connectionTestTable": "test"}`)
+		assertEqual(t, res.Status, backend.HealthStatusOk)
+		assertEqual(t, details.Checks[0].Name, "DescribeTable")
+		for _, c := range details.Checks {
+			if c.Name == "Query" || c.Name == "Scan" || c.Name == "PartiQLSelect" {
+				assertEqual(t, c.OK, true)
+			}
+		}
+	})
+
+	t.Run("missing test table", func(t *testing.T) {
+		res, _ := checkHealth(`{"connectionTestTable": "missing"}`)
+		assertEqual(t, res.Status, backend.HealthStatusError)
+	})
+}
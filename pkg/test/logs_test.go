@@ -0,0 +1,66 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestLogsFrame(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"ts": {N: aws.String("1730070193")}, "message": {S: aws.String("second")}, "level": {S: aws.String("error")}, "service": {S: aws.String("api")}},
+			{"ts": {N: aws.String("1730070176")}, "message": {S: aws.String("first")}, "level": {S: aws.String("info")}, "service": {S: aws.String("api")}},
+		},
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("A", output, plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{"ts": plugin.UnixTimestampSeconds},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = plugin.SetPrimaryTimeField(frame, "ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("body attribute", func(t *testing.T) {
+		logsFrame, err := plugin.LogsFrame(frame, "ts", "message", "level", []string{"service"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, logsFrame.Meta.Type, data.FrameTypeLogLines)
+		assertEqual(t, logsFrame.Meta.PreferredVisualization, data.VisType(data.VisTypeLogs))
+
+		body, _ := logsFrame.FieldByName("body")
+		assertEqual(t, body.At(0), "first")
+
+		severity, _ := logsFrame.FieldByName("severity")
+		assertEqual(t, severity.At(1), aws.String("error"))
+
+		labels, _ := logsFrame.FieldByName("labels")
+		assertEqual(t, labels.At(0), json.RawMessage(`{"service":"api"}`))
+	})
+
+	t.Run("item body", func(t *testing.T) {
+		logsFrame, err := plugin.LogsFrame(frame, "ts", "", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := logsFrame.FieldByName("body")
+		var item map[string]interface{}
+		err = json.Unmarshal([]byte(body.At(1).(string)), &item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, item["message"], "second")
+	})
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestNodeGraphFrames(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"PK": {S: aws.String("NODE#api")}, "SK": {S: aws.String("NODE#api")}, "name": {S: aws.String("API")}, "rps": {N: aws.String("120")}},
+			{"PK": {S: aws.String("NODE#api")}, "SK": {S: aws.String("EDGE#db")}},
+			{"PK": {S: aws.String("NODE#api")}, "SK": {S: aws.String("EDGE#cache")}},
+			{"PK": {S: aws.String("NODE#db")}, "SK": {S: aws.String("NODE#db")}, "name": {S: aws.String("Database")}, "rps": {N: aws.String("80")}},
+		},
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("A", output, plugin.DataFrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := plugin.NodeGraphFrames(frame, plugin.NodeGraphMapping{
+		NodeID:       "PK",
+		NodeTitle:    "name",
+		NodeMainStat: "rps",
+		EdgeSource:   "PK",
+		EdgeTarget:   "SK",
+		EdgePrefix:   "EDGE#",
+		TrimPrefixes: []string{"NODE#", "EDGE#"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, edges := frames[0], frames[1]
+	assertEqual(t, nodes.Name, "nodes")
+	assertEqual(t, nodes.Meta.PreferredVisualization, data.VisType(data.VisTypeNodeGraph))
+	assertEqual(t, nodes.Rows(), 3)
+	assertEqual(t, nodes.Fields[0].At(0), "api")
+	assertEqual(t, nodes.Fields[1].At(1), aws.String("Database"))
+	assertEqual(t, nodes.Fields[2].At(0), plugin.Pointer[int64](120))
+	assertEqual(t, nodes.Fields[0].At(2), "cache")
+
+	assertEqual(t, edges.Name, "edges")
+	assertEqual(t, edges.Rows(), 2)
+	assertEqual(t, edges.Fields[1].At(0), "api")
+	assertEqual(t, edges.Fields[2].At(0), "db")
+}
+
+func TestNodeGraphFramesWithoutEdgePrefix(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"PK": {S: aws.String("NODE#api")}, "SK": {S: aws.String("NODE#api")}, "name": {S: aws.String("API")}},
+			{"PK": {S: aws.String("NODE#api")}, "SK": {S: aws.String("NODE#db")}},
+			{"PK": {S: aws.String("NODE#db")}, "SK": {S: aws.String("NODE#db")}, "name": {S: aws.String("Database")}},
+		},
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("A", output, plugin.DataFrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := plugin.NodeGraphFrames(frame, plugin.NodeGraphMapping{
+		NodeID:       "PK",
+		NodeTitle:    "name",
+		EdgeSource:   "PK",
+		EdgeTarget:   "SK",
+		TrimPrefixes: []string{"NODE#"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, edges := frames[0], frames[1]
+	assertEqual(t, nodes.Rows(), 2)
+	assertEqual(t, nodes.Fields[1].At(0), aws.String("API"))
+	assertEqual(t, nodes.Fields[1].At(1), aws.String("Database"))
+
+	assertEqual(t, edges.Rows(), 1)
+	assertEqual(t, edges.Fields[0].At(0), "api->db")
+}
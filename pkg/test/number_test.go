@@ -0,0 +1,85 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestNumberPrecision(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"id":    {N: aws.String("123456789012345678901234567890")},
+				"price": {N: aws.String("0.1")},
+				"ids":   {NS: []*string{aws.String("12345678901234567890123")}},
+			},
+			{
+				"id":    {N: aws.String("1")},
+				"price": {N: aws.String("2")},
+			},
+		},
+	}
+
+	t.Run("string", func(t *testing.T) {
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			NumberPrecision: plugin.NumberPrecisionString,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, _ := frame.FieldByName("id")
+		assertEqual(t, id.Type(), data.FieldTypeNullableString)
+		assertEqual(t, id.At(0), aws.String("123456789012345678901234567890"))
+
+		ids, _ := frame.FieldByName("ids")
+		assertEqual(t, ids.At(0), plugin.Pointer(json.RawMessage(`["12345678901234567890123"]`)))
+	})
+
+	t.Run("float with attribute override", func(t *testing.T) {
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			NumberPrecision: plugin.NumberPrecisionFloat,
+			NumberPrecisionAttributes: map[string]string{
+				"id": plugin.NumberPrecisionString,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, _ := frame.FieldByName("id")
+		assertEqual(t, id.Type(), data.FieldTypeNullableString)
+
+		price, _ := frame.FieldByName("price")
+		assertEqual(t, price.Type(), data.FieldTypeNullableFloat64)
+		assertEqual(t, price.At(1), aws.Float64(2))
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			NumberPrecision: plugin.NumberPrecisionStrict,
+		})
+		if err == nil {
+			t.Error("expected precision loss error")
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			NumberPrecision: plugin.NumberPrecisionStrict,
+			NumberPrecisionAttributes: map[string]string{
+				"id":  plugin.NumberPrecisionString,
+				"ids": plugin.NumberPrecisionString,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		price, _ := frame.FieldByName("price")
+		assertEqual(t, price.At(0), aws.Float64(0.1))
+	})
+}
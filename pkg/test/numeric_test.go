@@ -0,0 +1,80 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestNumericFrame(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{"cpu": {N: aws.String("0.5")}, "host": {S: aws.String("a")}, "zone": {N: aws.String("1")}},
+			{"cpu": {N: aws.String("0.7")}, "host": {S: aws.String("b")}, "zone": {N: aws.String("2")}},
+		},
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("A", output, plugin.DataFrameOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("long", func(t *testing.T) {
+		numericFrame, err := plugin.NumericFrame(frame, "cpu", []string{"host", "zone"}, plugin.NumericFormatLong)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, numericFrame.Meta.Type, data.FrameTypeNumericLong)
+		assertEqual(t, len(numericFrame.Fields), 3)
+		assertEqual(t, numericFrame.Fields[0].Name, "cpu")
+		assertEqual(t, numericFrame.Fields[2].At(1), aws.String("2"))
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		numericFrame, err := plugin.NumericFrame(frame, "cpu", []string{"host"}, plugin.NumericFormatWide)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, numericFrame.Meta.Type, data.FrameTypeNumericWide)
+		assertEqual(t, len(numericFrame.Fields), 2)
+		assertEqual(t, numericFrame.Fields[1].Labels, data.Labels{"host": "b"})
+		assertEqual(t, numericFrame.Fields[1].At(0), aws.Float64(0.7))
+	})
+
+	t.Run("non-numeric value", func(t *testing.T) {
+		_, err := plugin.NumericFrame(frame, "host", nil, plugin.NumericFormatLong)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("no value attribute", func(t *testing.T) {
+		_, err := plugin.NumericFrame(frame, "", nil, plugin.NumericFormatLong)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("missing value attribute", func(t *testing.T) {
+		_, err := plugin.NumericFrame(frame, "memory", nil, plugin.NumericFormatLong)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		numericFrame, err := plugin.NumericFrame(data.NewFrame("response"), "cpu", []string{"host"}, plugin.NumericFormatLong)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, numericFrame.Meta.Type, data.FrameTypeNumericLong)
+		assertEqual(t, len(numericFrame.Fields), 2)
+		assertEqual(t, numericFrame.Rows(), 0)
+	})
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestQueryOverrides(t *testing.T) {
+	var mu sync.Mutex
+	var authorizations []string
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[]}`))
+	})
+	ds.ExtraSettings = plugin.ExtraPluginSettings{AllowedRegions: []string{"eu-west-1"}}
+
+	query := func(qm plugin.QueryModel) backend.DataResponse {
+		rawJson, err := json.Marshal(qm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: rawJson}},
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses["A"]
+	}
+
+	res := query(plugin.QueryModel{QueryText: "SELECT * FROM test"})
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	res = query(plugin.QueryModel{QueryText: "SELECT * FROM test", Region: "eu-west-1"})
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	assertEqual(t, len(authorizations), 2)
+	assertEqual(t, strings.Contains(authorizations[0], "/us-east-1/dynamodb/"), true)
+	assertEqual(t, strings.Contains(authorizations[1], "/eu-west-1/dynamodb/"), true)
+
+	res = query(plugin.QueryModel{QueryText: "SELECT * FROM test", Region: "ap-south-1"})
+	assertEqual(t, res.Status, backend.StatusForbidden)
+
+	res = query(plugin.QueryModel{QueryText: "SELECT * FROM test", AssumeRoleARN: "arn:aws:iam::123456789012:role/other"})
+	assertEqual(t, res.Status, backend.StatusForbidden)
+	assertEqual(t, len(authorizations), 2)
+}
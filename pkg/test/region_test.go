@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestMultiRegionQuery(t *testing.T) {
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		switch {
+		case strings.Contains(authorization, "/ap-south-1/"):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"Table not found"}`))
+		case strings.Contains(authorization, "/eu-west-1/"):
+			_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"3"}}]}`))
+		case strings.Contains(authorization, "/eu-central-1/"):
+			_, _ = w.Write([]byte(`{"Items":[]}`))
+		case strings.Contains(authorization, "/sa-east-1/"):
+			_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"4"},"region":{"S":"south"}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"1"}},{"id":{"N":"2"}}]}`))
+		}
+	})
+	ds.ExtraSettings = plugin.ExtraPluginSettings{AllowedRegions: []string{"eu-west-1", "ap-south-1", "eu-central-1", "sa-east-1"}}
+
+	query := func(qm plugin.QueryModel) backend.DataResponse {
+		rawJson, err := json.Marshal(qm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: rawJson}},
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses["A"]
+	}
+
+	t.Run("column", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"us-east-1", "eu-west-1", "ap-south-1"}})
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+
+		assertEqual(t, len(res.Frames), 1)
+		frame := res.Frames[0]
+		regionField, _ := frame.FieldByName(plugin.RegionAttribute)
+		assertEqual(t, regionField.Len(), 3)
+		assertEqual(t, getFieldValue[string](t, regionField, 0), "us-east-1")
+		assertEqual(t, getFieldValue[string](t, regionField, 2), "eu-west-1")
+		assertEqual(t, len(frame.Meta.Notices), 1)
+		assertEqual(t, frame.Meta.Notices[0].Severity, data.NoticeSeverityWarning)
+		assertEqual(t, strings.HasPrefix(frame.Meta.Notices[0].Text, "region ap-south-1:"), true)
+	})
+
+	t.Run("series", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"us-east-1", "eu-west-1"}, RegionOutput: plugin.RegionOutputSeries})
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+
+		assertEqual(t, len(res.Frames), 2)
+		assertEqual(t, res.Frames[0].Name, "us-east-1")
+		assertEqual(t, res.Frames[1].Name, "eu-west-1")
+		assertEqual(t, res.Frames[1].Fields[0].Labels, data.Labels{"region": "eu-west-1"})
+		_, index := res.Frames[0].FieldByName(plugin.RegionAttribute)
+		assertEqual(t, index, -1)
+	})
+
+	t.Run("series without items", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"eu-central-1", "ap-south-1"}, RegionOutput: plugin.RegionOutputSeries})
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+
+		assertEqual(t, len(res.Frames), 1)
+		assertEqual(t, len(res.Frames[0].Meta.Notices), 1)
+		assertEqual(t, strings.HasPrefix(res.Frames[0].Meta.Notices[0].Text, "region ap-south-1:"), true)
+	})
+
+	t.Run("region attribute", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"us-east-1", "sa-east-1"}})
+		assertEqual(t, res.Status, backend.StatusBadRequest)
+	})
+
+	t.Run("extracted region attribute", func(t *testing.T) {
+		res := query(plugin.QueryModel{
+			QueryText:           "SELECT * FROM test",
+			Regions:             []string{"us-east-1", "eu-west-1"},
+			ExtractedAttributes: []plugin.ExtractedAttribute{{Name: plugin.RegionAttribute, Path: "address.region"}},
+		})
+		assertEqual(t, res.Status, backend.StatusBadRequest)
+	})
+
+	t.Run("all regions failed", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"ap-south-1", "ap-south-1"}})
+		assertEqual(t, res.Error != nil, true)
+	})
+
+	t.Run("region not allowed", func(t *testing.T) {
+		res := query(plugin.QueryModel{QueryText: "SELECT * FROM test", Regions: []string{"us-east-1", "us-west-2"}})
+		assertEqual(t, res.Status, backend.StatusForbidden)
+	})
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestAnalyzeStatement(t *testing.T) {
+	table := &dynamodb.TableDescription{
+		TableName: aws.String("Orders"),
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("PK"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+			{AttributeName: aws.String("SK"), KeyType: aws.String(dynamodb.KeyTypeRange)},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{
+			{
+				IndexName: aws.String("byStatus"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("status"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		statement string
+		operation string
+	}{
+		{`SELECT * FROM Orders`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE PK = 'CUSTOMER#1'`, plugin.OperationQuery},
+		{`SELECT * FROM "Orders" WHERE "PK" IN ['CUSTOMER#1', 'CUSTOMER#2'] ORDER BY SK`, plugin.OperationQuery},
+		{`SELECT * FROM Orders WHERE 'CUSTOMER#1' = PK AND begins_with(SK, 'ORDER#')`, plugin.OperationQuery},
+		{`SELECT * FROM Orders WHERE (PK = 'CUSTOMER#1' AND SK > 'A') AND total > 10`, plugin.OperationQuery},
+		{`SELECT * FROM Orders WHERE PK = 'CUSTOMER#1' OR total > 10`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE (PK = 'CUSTOMER#1') OR (PK = 'CUSTOMER#2')`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE SK = 'PK'`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE PKX = 'a'`, plugin.OperationScan},
+		{`SELECT * FROM Orders.byStatus WHERE status = 'OPEN'`, plugin.OperationQuery},
+		{`SELECT * FROM "Orders"."byStatus" WHERE PK = 'CUSTOMER#1'`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE 'a' <= PK`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE x != PK`, plugin.OperationScan},
+		{`SELECT * FROM Orders WHERE x<>PK`, plugin.OperationScan},
+		{`SELECT * FROM"Orders"WHERE PK='a'`, plugin.OperationQuery},
+		{`SELECT * FROM Orders WHERE PK = 'a') OR (1 = 1`, plugin.OperationScan},
+	}
+
+	for _, test := range tests {
+		plan, err := plugin.AnalyzeStatement(test.statement, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Operation != test.operation {
+			t.Errorf("%s: expected %s, got %s", test.statement, test.operation, plan.Operation)
+		}
+	}
+
+	_, err := plugin.AnalyzeStatement(`SELECT * FROM Orders.missing WHERE PK = 'a'`, table)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, statement := range []string{
+		`SELECT "a from Settings" FROM Orders`,
+		`SELECT * FROM"Orders"`,
+		`SELECT * FROM Orders -- FROM Settings`,
+	} {
+		plan, err := plugin.AnalyzeStatement(statement, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Table != "Orders" {
+			t.Errorf("%s: expected table Orders, got %s", statement, plan.Table)
+		}
+	}
+}
+
+func TestScanGuard(t *testing.T) {
+	var statements []string
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		if strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".DescribeTable") {
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), "Private") {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#AccessDeniedException","message":"Not authorized to perform dynamodb:DescribeTable"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"Table":{"TableName":"Orders","KeySchema":[{"AttributeName":"PK","KeyType":"HASH"}]}}`))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var input struct{ Statement string }
+		_ = json.Unmarshal(body, &input)
+		statements = append(statements, input.Statement)
+		_, _ = w.Write([]byte(`{"Items":[]}`))
+	})
+	ds.ExtraSettings = plugin.ExtraPluginSettings{ScanGuard: plugin.ScanGuardBlock, ScanAllowedTables: []string{"Settings"}}
+
+	query := func(t *testing.T, statement string) backend.DataResponse {
+		rawJson, err := json.Marshal(plugin.QueryModel{
+			QueryText:           statement,
+			TimeFilterAttribute: "ts",
+			DatetimeAttributes:  []plugin.DatetimeAttribute{{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: rawJson}},
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses["A"]
+	}
+
+	tests := []struct {
+		statement string
+		blocked   bool
+	}{
+		{`SELECT * FROM Orders WHERE PK = 'a'`, false},
+		{`SELECT * FROM Orders`, true},
+		{`SELECT "a from Settings" FROM Orders`, true},
+		{`SELECT * FROM Orders WHERE 'a' <= PK`, true},
+		{`SELECT * FROM Orders WHERE PK = 'a') OR (1 = 1`, true},
+		{`SELECT * FROM Private WHERE PK = 'a'`, true},
+		{`UPDATE Orders SET total = 1 WHERE PK = 'a'`, false},
+	}
+
+	for _, test := range tests {
+		statements = nil
+		res := query(t, test.statement)
+		if test.blocked {
+			assertEqual(t, res.Status, backend.StatusBadRequest)
+			assertEqual(t, len(statements), 0)
+		} else {
+			if res.Error != nil {
+				t.Fatalf("%s: %s", test.statement, res.Error)
+			}
+			assertEqual(t, len(statements), 1)
+		}
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		ds.ExtraSettings.ScanGuard = plugin.ScanGuardWarn
+		defer func() { ds.ExtraSettings.ScanGuard = plugin.ScanGuardBlock }()
+
+		for _, statement := range []string{`SELECT * FROM Orders`, `SELECT * FROM Private WHERE PK = 'a'`} {
+			statements = nil
+			res := query(t, statement)
+			if res.Error != nil {
+				t.Fatalf("%s: %s", statement, res.Error)
+			}
+			assertEqual(t, len(statements), 1)
+			assertEqual(t, len(res.Frames[0].Meta.Notices), 1)
+			assertEqual(t, res.Frames[0].Meta.Notices[0].Severity, data.NoticeSeverityWarning)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestFilterItemsByTime(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"ts": {S: aws.String("2024-10-31T10:00:00Z")}, "id": {N: aws.String("1")}},
+		{"ts": {S: aws.String("2024-10-31T12:00:00Z")}, "id": {N: aws.String("2")}},
+		{"ts": {S: aws.String("2024-10-31T14:00:00Z")}, "id": {N: aws.String("3")}},
+		{"id": {N: aws.String("4")}},
+	}
+
+	filtered, err := plugin.FilterItemsByTime(items, "ts", &plugin.DatetimeOptions{
+		Format:   plugin.DatetimeFormatAuto,
+		Location: time.UTC,
+	}, backend.TimeRange{
+		From: time.Date(2024, 10, 31, 11, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 10, 31, 14, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, len(filtered), 2)
+	assertEqual(t, *filtered[0]["id"].N, "2")
+	assertEqual(t, *filtered[1]["id"].N, "3")
+}
+
+func TestTimeFilterPushDown(t *testing.T) {
+	var statement string
+	ds := newFakeDatasource(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var input struct{ Statement string }
+		_ = json.Unmarshal(body, &input)
+		statement = input.Statement
+
+		// The server doesn't evaluate the condition, the items outside of the time range are filtered afterwards
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = w.Write([]byte(`{"Items":[{"id":{"N":"1"},"ts":{"N":"1730368800"}},{"id":{"N":"2"},"ts":{"S":"1730376000"}},{"id":{"N":"3"},"ts":{"S":"173037600"}}]}`))
+	})
+
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 10, 31, 11, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 10, 31, 14, 0, 0, 0, time.UTC),
+	}
+
+	query := func(t *testing.T, qm plugin.QueryModel) data.Frames {
+		qm.TimeFilterAttribute = "ts"
+		rawJson, err := json.Marshal(qm)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: rawJson, TimeRange: timeRange}},
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{},
+				GrafanaConfig:              backend.NewGrafanaCfg(map[string]string{})},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Responses["A"].Error != nil {
+			t.Fatal(resp.Responses["A"].Error)
+		}
+		return resp.Responses["A"].Frames
+	}
+
+	assertIds := func(t *testing.T, frames data.Frames, ids ...int64) {
+		field, _ := frames[0].FieldByName("id")
+		assertEqual(t, field.Len(), len(ids))
+		for i, id := range ids {
+			assertEqual(t, getFieldValue[int64](t, field, i), id)
+		}
+	}
+
+	t.Run("epoch", func(t *testing.T) {
+		frames := query(t, plugin.QueryModel{
+			QueryText:          "SELECT * FROM test WHERE id > 0",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds}},
+		})
+		assertEqual(t, statement, `SELECT * FROM test WHERE (id > 0) AND ("ts" BETWEEN 1730372400 AND 1730383200 OR "ts" BETWEEN '1730372400' AND '1730383200')`)
+		assertIds(t, frames, 2)
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		frames := query(t, plugin.QueryModel{
+			QueryText:          "SELECT * FROM test -- all items",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds}},
+		})
+		assertEqual(t, statement, "SELECT * FROM test -- all items")
+		assertIds(t, frames, 2)
+	})
+
+	t.Run("unbalanced brackets", func(t *testing.T) {
+		frames := query(t, plugin.QueryModel{
+			QueryText:          "SELECT * FROM test WHERE id > 0) OR (1 = 1",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds}},
+		})
+		assertEqual(t, statement, "SELECT * FROM test WHERE id > 0) OR (1 = 1")
+		assertIds(t, frames, 2)
+	})
+
+	t.Run("fallbacks", func(t *testing.T) {
+		frames := query(t, plugin.QueryModel{
+			QueryText:          "SELECT * FROM test",
+			DatetimeAttributes: []plugin.DatetimeAttribute{{Name: "ts", Format: plugin.DatetimeFormatUnixSeconds, Fallbacks: []string{plugin.DatetimeFormatAuto}}},
+		})
+		assertEqual(t, strings.Contains(statement, "BETWEEN"), false)
+		assertIds(t, frames, 2)
+	})
+}
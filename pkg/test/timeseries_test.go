@@ -0,0 +1,65 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestSetPrimaryTimeField(t *testing.T) {
+	options := plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{"ts": plugin.UnixTimestampSeconds},
+	}
+
+	t.Run("wide", func(t *testing.T) {
+		output := &dynamodb.ExecuteStatementOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"ts": {N: aws.String("1730070193")}, "value": {N: aws.String("2")}},
+				{"value": {N: aws.String("3")}},
+				{"ts": {N: aws.String("1730070176")}, "value": {N: aws.String("1")}},
+			},
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = plugin.SetPrimaryTimeField(frame, "ts")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, frame.Fields[0].Name, "ts")
+		assertEqual(t, frame.Meta.Type, data.FrameTypeTimeSeriesWide)
+		assertEqual(t, frame.Rows(), 2)
+		assertEqual(t, getFieldValue[time.Time](t, frame.Fields[0], 0).Unix(), int64(1730070176))
+		assertEqual(t, frame.Fields[1].At(0), plugin.Pointer[int64](1))
+	})
+
+	t.Run("long", func(t *testing.T) {
+		output := &dynamodb.ExecuteStatementOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"ts": {N: aws.String("1730070176")}, "value": {N: aws.String("1")}, "host": {S: aws.String("a")}},
+				{"ts": {N: aws.String("1730070176")}, "value": {N: aws.String("2")}, "host": {S: aws.String("b")}},
+			},
+		}
+
+		frame, err := plugin.QueryResultToDataFrame("test", output, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = plugin.SetPrimaryTimeField(frame, "ts")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertEqual(t, frame.Meta.Type, data.FrameTypeTimeSeriesLong)
+		assertEqual(t, frame.Meta.TypeVersion, data.FrameTypeVersion{0, 1})
+	})
+}
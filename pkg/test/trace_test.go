@@ -0,0 +1,64 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestTraceFrame(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"traceId": {S: aws.String("t1")}, "spanId": {S: aws.String("s1")},
+				"service": {S: aws.String("api")}, "op": {S: aws.String("GET /orders")},
+				"start": {S: aws.String("2024-10-31T21:04:29.123Z")}, "durationUs": {N: aws.String("1500")},
+				"http.status": {N: aws.String("200")},
+			},
+			{
+				"traceId": {S: aws.String("t1")}, "spanId": {S: aws.String("s2")}, "parentId": {S: aws.String("s1")},
+				"service": {S: aws.String("db")}, "op": {S: aws.String("query")},
+				"start": {S: aws.String("2024-10-31T21:04:29.124Z")}, "durationUs": {N: aws.String("500")},
+			},
+		},
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("A", output, plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{"start": plugin.DatetimeFormatAuto},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceFrame, err := plugin.TraceFrame(frame, plugin.TraceMapping{
+		TraceID:       "traceId",
+		SpanID:        "spanId",
+		ParentSpanID:  "parentId",
+		ServiceName:   "service",
+		OperationName: "op",
+		StartTime:     "start",
+		Duration:      "durationUs",
+		DurationUnit:  "us",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqual(t, traceFrame.Meta.PreferredVisualization, data.VisType(data.VisTypeTrace))
+
+	startTime, _ := traceFrame.FieldByName("startTime")
+	assertEqual(t, startTime.At(0), float64(1730408669123))
+
+	duration, _ := traceFrame.FieldByName("duration")
+	assertEqual(t, duration.At(0), 1.5)
+
+	parentSpanID, _ := traceFrame.FieldByName("parentSpanID")
+	assertEqual(t, parentSpanID.At(1), aws.String("s1"))
+
+	tags, _ := traceFrame.FieldByName("tags")
+	assertEqual(t, tags.At(0), json.RawMessage(`[{"key":"http.status","value":200}]`))
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestAttributeTypes(t *testing.T) {
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: []map[string]*dynamodb.AttributeValue{
+			{
+				"temperature": {S: aws.String("42.5")},
+				"count":       {S: aws.String("3")},
+				"enabled":     {S: aws.String("true")},
+				"flag":        {N: aws.String("1")},
+				"createdAt":   {S: aws.String("2024-10-27T23:10:42.951Z")},
+				"name":        {S: aws.String("a")},
+			},
+			{
+				"temperature": {S: aws.String("41")},
+				"count":       {NULL: aws.Bool(true)},
+				"enabled":     {S: aws.String("0")},
+				"flag":        {N: aws.String("0")},
+				"createdAt":   {S: aws.String("2024-10-27T23:10:49Z")},
+				"name":        {S: aws.String("1")},
+			},
+		},
+	}
+
+	t.Run("overrides", func(t *testing.T) {
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			AttributeTypes: map[string]string{
+				"temperature": plugin.AttributeTypeFloat,
+				"enabled":     plugin.AttributeTypeBool,
+				"flag":        plugin.AttributeTypeBool,
+				"createdAt":   plugin.AttributeTypeTime,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		temperature, _ := frame.FieldByName("temperature")
+		assertEqual(t, temperature.Type(), data.FieldTypeNullableFloat64)
+		assertEqual(t, temperature.At(1), aws.Float64(41))
+
+		enabled, _ := frame.FieldByName("enabled")
+		assertEqual(t, enabled.At(0), aws.Bool(true))
+		assertEqual(t, enabled.At(1), aws.Bool(false))
+
+		flag, _ := frame.FieldByName("flag")
+		assertEqual(t, flag.Type(), data.FieldTypeNullableBool)
+
+		createdAt, _ := frame.FieldByName("createdAt")
+		assertEqual(t, getFieldValue[time.Time](t, createdAt, 0).UnixMilli(), int64(1730070642951))
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		_, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			AttributeTypes: map[string]string{
+				"name": plugin.AttributeTypeInt,
+			},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		frame, err := plugin.QueryResultToDataFrame("test", &dynamodb.ExecuteStatementOutput{
+			Items: []map[string]*dynamodb.AttributeValue{
+				{"payload": {S: aws.String("42")}},
+				{"payload": {S: aws.String(`"x"`)}},
+				{"payload": {S: aws.String(`{"a":1}`)}},
+				{"payload": {N: aws.String("7")}},
+				{"payload": {M: map[string]*dynamodb.AttributeValue{"b": {BOOL: aws.Bool(true)}}}},
+				{"payload": {NULL: aws.Bool(true)}},
+			},
+		}, plugin.DataFrameOptions{
+			AttributeTypes: map[string]string{
+				"payload": plugin.AttributeTypeJSON,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, _ := frame.FieldByName("payload")
+		assertEqual(t, payload.Type(), data.FieldTypeNullableJSON)
+		expected := []string{`42`, `"x"`, `{"a":1}`, `7`, `{"b":true}`}
+		for i, e := range expected {
+			assertEqual(t, string(getFieldValue[json.RawMessage](t, payload, i)), e)
+		}
+		assertEqual(t, payload.At(5), (*json.RawMessage)(nil))
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			AttributeTypes: map[string]string{
+				"createdAt": plugin.AttributeTypeJSON,
+			},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("infer", func(t *testing.T) {
+		frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+			InferTypes: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		temperature, _ := frame.FieldByName("temperature")
+		assertEqual(t, temperature.Type(), data.FieldTypeNullableFloat64)
+
+		count, _ := frame.FieldByName("count")
+		assertEqual(t, count.Type(), data.FieldTypeNullableInt64)
+
+		name, _ := frame.FieldByName("name")
+		assertEqual(t, name.Type(), data.FieldTypeNullableString)
+
+		enabled, _ := frame.FieldByName("enabled")
+		assertEqual(t, enabled.Type(), data.FieldTypeNullableString)
+	})
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/haohanyang/dynamodb-datasource/pkg/plugin"
+)
+
+func TestUnnestItems(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{
+			"device": {S: aws.String("d1")},
+			"readings": {L: []*dynamodb.AttributeValue{
+				{M: map[string]*dynamodb.AttributeValue{
+					"t": {N: aws.String("1730070176")},
+					"v": {N: aws.String("1.5")},
+				}},
+				{M: map[string]*dynamodb.AttributeValue{
+					"t": {N: aws.String("1730070193")},
+					"v": {N: aws.String("2.5")},
+				}},
+			}},
+		},
+		{
+			"device": {S: aws.String("d2")},
+		},
+	}
+
+	output := &dynamodb.ExecuteStatementOutput{
+		Items: plugin.UnnestItems(items, "readings"),
+	}
+
+	frame, err := plugin.QueryResultToDataFrame("test", output, plugin.DataFrameOptions{
+		DatetimeAttributes: map[string]string{
+			"readings.t": plugin.UnixTimestampSeconds,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := frame.RowLen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, size, 3)
+
+	device, _ := frame.FieldByName("device")
+	assertEqual(t, device.At(0), plugin.Pointer("d1"))
+	assertEqual(t, device.At(1), plugin.Pointer("d1"))
+	assertEqual(t, device.At(2), plugin.Pointer("d2"))
+
+	ts, _ := frame.FieldByName("readings.t")
+	assertEqual(t, ts.Type(), data.FieldTypeNullableTime)
+
+	v, _ := frame.FieldByName("readings.v")
+	assertEqual(t, v.At(1), plugin.Pointer(2.5))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"testing"
@@ -20,6 +22,20 @@ import (
 var endpoint = "http://localhost:4566"
 var testTableName = "test"
 
+// newFakeDatasource returns a datasource whose DynamoDB endpoint is a test server with the handler
+func newFakeDatasource(tb testing.TB, handler http.HandlerFunc) *plugin.Datasource {
+	// The SDK can't load a custom CA bundle into the HTTP client of the plugin
+	tb.Setenv("AWS_CA_BUNDLE", "")
+
+	server := httptest.NewServer(handler)
+	tb.Cleanup(server.Close)
+
+	ds := plugin.CreateTestDatasource(context.Background())
+	ds.Settings.Endpoint = server.URL
+	tb.Cleanup(ds.Dispose)
+	return ds
+}
+
 func testClient() (*dynamodb.DynamoDB, error) {
 	sess, err := session.NewSession(&aws.Config{
 		Endpoint:    aws.String(endpoint),
@@ -1,8 +1,8 @@
 import React, { useRef, useState } from "react";
-import { Button, CodeEditor, Field, IconButton, InlineField, InlineFieldRow, Input, Select } from "@grafana/ui";
-import { QueryEditorProps, SelectableValue } from "@grafana/data";
+import { Alert, Button, CodeEditor, Field, IconButton, InlineField, InlineFieldRow, Input, Select } from "@grafana/ui";
+import { QueryEditorProps, SelectableValue, getDefaultTimeRange } from "@grafana/data";
 import { DataSource } from "../datasource";
-import { DynamoDBDataSourceOptions, DynamoDBQuery, DatetimeFormat } from "../types";
+import { DynamoDBDataSourceOptions, DynamoDBQuery, DatetimeFormat, ExplainResponse } from "../types";
 import * as monacoType from "monaco-editor/esm/vs/editor/editor.api";
 import "./QueryEditor.css";
 import { Divider } from "@grafana/aws-sdk";
@@ -28,8 +28,10 @@ const datetimeFormatOptions: Array<SelectableValue<string>> = [
 ];
 
 
-export function QueryEditor({ query, onChange }: Props) {
+export function QueryEditor({ query, onChange, datasource, range }: Props) {
   const codeEditorRef = useRef<monacoType.editor.IStandaloneCodeEditor | null>(null);
+  const [explanation, setExplanation] = useState<ExplainResponse | undefined>();
+  const [explainError, setExplainError] = useState<string | undefined>();
   const [datetimeAttributeInput, setDatetimeAttributeInput] = useState<string>("");
   const [datetimeFormatOption, setDatetimeFormatOption] = useState<string>(DatetimeFormat.UnixTimestampSeconds);
   const [customDatetimeFormatInput, setCustomDatetimeFormatInput] = useState<string>("");
@@ -56,6 +58,20 @@ export function QueryEditor({ query, onChange }: Props) {
     }
   };
 
+  const onExplain = () => {
+    // The editor only reports its text on blur, so read the latest text from it
+    const queryText = codeEditorRef.current?.getValue() ?? query.queryText;
+    datasource.explain({ ...query, queryText }, range ?? getDefaultTimeRange())
+      .then(response => {
+        setExplanation(response);
+        setExplainError(undefined);
+      })
+      .catch(err => {
+        setExplanation(undefined);
+        setExplainError(err?.data?.error ?? err?.message ?? "Failed to explain the query");
+      });
+  };
+
   const onAddDatetimeField = () => {
     let format = "";
     if (datetimeFormatOption === DatetimeFormat.UnixTimestampMiniseconds || datetimeFormatOption === DatetimeFormat.UnixTimestampSeconds) {
@@ -137,7 +153,19 @@ export function QueryEditor({ query, onChange }: Props) {
           onEditorDidMount={onCodeEditorDidMount}
         />
       </Field>
-      <Button onClick={onFormatQueryText}>Format</Button>
+      <InlineFieldRow>
+        <Button onClick={onFormatQueryText}>Format</Button>
+        <Button variant="secondary" onClick={onExplain} data-testid="explain-button">Explain</Button>
+      </InlineFieldRow>
+      {explainError && <Alert title="Explain failed" severity="error">{explainError}</Alert>}
+      {explanation &&
+        <Alert title={explanation.operation === "Scan" ? "Scan: reads every item of the table" : "Query: reads the selected partitions"}
+          severity={explanation.operation === "Scan" ? "warning" : "info"} onRemove={() => setExplanation(undefined)}>
+          <div>Table: {explanation.table}{explanation.index ? ` (index ${explanation.index})` : ""}</div>
+          <div>Partition key: {explanation.partitionKey}</div>
+          <div>Estimated items: {explanation.estimatedItemCount.toLocaleString()}</div>
+          <div>Statement: <code>{explanation.statement}</code></div>
+        </Alert>}
     </>
   );
 }
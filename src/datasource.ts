@@ -1,7 +1,7 @@
-import { DataSourceInstanceSettings, CoreApp, ScopedVars, DataQueryRequest, DataQueryResponse } from "@grafana/data";
+import { DataSourceInstanceSettings, CoreApp, ScopedVars, DataQueryRequest, DataQueryResponse, TimeRange } from "@grafana/data";
 import { DataSourceWithBackend, getTemplateSrv } from "@grafana/runtime";
 import { Observable } from "rxjs";
-import { DynamoDBQuery, DynamoDBDataSourceOptions, DEFAULT_QUERY, DatetimeFormat } from "./types";
+import { DynamoDBQuery, DynamoDBDataSourceOptions, DEFAULT_QUERY, NamedDatetimeFormats, ExplainResponse } from "./types";
 import { formatRefTime } from "./utils";
 
 export class DataSource extends DataSourceWithBackend<DynamoDBQuery, DynamoDBDataSourceOptions> {
@@ -26,20 +26,43 @@ export class DataSource extends DataSourceWithBackend<DynamoDBQuery, DynamoDBDat
   }
 
   query(request: DataQueryRequest<DynamoDBQuery>): Observable<DataQueryResponse> {
-    const queries = request.targets.map((query) => {
-      return {
-        ...query,
-        queryText:
-          query.queryText?.replaceAll(/\$from/g, Math.floor(request.range.from.toDate().getTime() / 1000).toString())
-            .replaceAll(/\$to/g, Math.floor(request.range.to.toDate().getTime() / 1000).toString()),
-        datetimeAttributes: query.datetimeAttributes.map(field => {
-          if (field.format !== DatetimeFormat.UnixTimestampSeconds && field.format !== DatetimeFormat.UnixTimestampMiniseconds) {
-            return { ...field, format: formatRefTime(field.format) };
-          }
-          return field;
-        })
-      };
-    });
+    const queries = request.targets.map((query) => toBackendQuery(query, request.range));
     return super.query({ ...request, targets: queries });
   }
+
+  // Returns the table, index and operation (Query or Scan) of the query and the statement sent to DynamoDB,
+  // without reading any items
+  explain(query: DynamoDBQuery, range: TimeRange): Promise<ExplainResponse> {
+    return this.postResource<ExplainResponse>("explain", {
+      query: toBackendQuery(this.applyTemplateVariables(query, {}), range),
+      timeRange: { from: range.from.toISOString(), to: range.to.toISOString() },
+    });
+  }
+}
+
+function toBackendQuery(query: DynamoDBQuery, range: TimeRange): DynamoDBQuery {
+  return {
+    ...query,
+    queryText:
+      query.queryText?.replaceAll(/\$from/g, Math.floor(range.from.toDate().getTime() / 1000).toString())
+        .replaceAll(/\$to/g, Math.floor(range.to.toDate().getTime() / 1000).toString()),
+    datetimeAttributes: query.datetimeAttributes.map(field => ({
+      ...field,
+      format: toBackendFormat(field.format),
+      fallbacks: field.fallbacks?.map(toBackendFormat)
+    })),
+    extractedAttributes: query.extractedAttributes?.map(attribute => {
+      if (attribute.format) {
+        return { ...attribute, format: toBackendFormat(attribute.format) };
+      }
+      return attribute;
+    })
+  };
+}
+
+function toBackendFormat(format: string) {
+  if (NamedDatetimeFormats.includes(format)) {
+    return format;
+  }
+  return formatRefTime(format);
 }
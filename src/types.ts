@@ -5,6 +5,32 @@ export interface DynamoDBQuery extends DataQuery {
   queryText?: string;
   limit?: number;
   datetimeAttributes: DatetimeAttribute[];
+  flattenDepth?: number;
+  flattenListMode?: string;
+  extractedAttributes?: ExtractedAttribute[];
+  compositeAttributes?: CompositeAttribute[];
+  unnestAttribute?: string;
+  binaryAttributes?: BinaryAttribute[];
+  numberPrecision?: string;
+  numberPrecisionAttributes?: NumberPrecisionAttribute[];
+  typedAttributes?: TypedAttribute[];
+  inferTypes?: boolean;
+  timeFilterAttribute?: string;
+  primaryTimeAttribute?: string;
+  outputMode?: string;
+  valueAttribute?: string;
+  dimensionAttributes?: string[];
+  numericFormat?: string;
+  bodyAttribute?: string;
+  severityAttribute?: string;
+  labelAttributes?: string[];
+  traceMapping?: TraceMapping;
+  nodeGraphMapping?: NodeGraphMapping;
+  entityDiscriminator?: EntityDiscriminator;
+  region?: string;
+  assumeRoleArn?: string;
+  regions?: string[];
+  regionOutput?: string;
 }
 
 export const DEFAULT_QUERY: Partial<DynamoDBQuery> = {
@@ -14,6 +40,36 @@ export const DEFAULT_QUERY: Partial<DynamoDBQuery> = {
 
 export interface DynamoDBDataSourceOptions extends AwsAuthDataSourceJsonData {
   connectionTestTable?: string;
+  datetimeAttributes?: DefaultDatetimeAttribute[];
+  scanGuard?: string;
+  scanAllowedTables?: string[];
+  maxConcurrentQueries?: number;
+  queryTimeout?: string;
+  allowedRegions?: string[];
+  allowedAssumeRoleArns?: string[];
+  accessRules?: AccessRule[];
+  auditLog?: boolean;
+  auditLogLevel?: string;
+  auditLogFile?: string;
+  auditSensitiveAttributes?: string[];
+}
+
+export interface AccessRule {
+  table?: string;
+  users?: string[];
+  roles?: string[];
+  predicate: string;
+}
+
+export interface ExplainResponse {
+  table: string;
+  index?: string;
+  operation: "Query" | "Scan";
+  partitionKey: string;
+  statement: string;
+  estimatedItemCount: number;
+  sizeBytes: number;
+  postFilter: boolean;
 }
 
 export interface DynamoDBDataSourceSecureJsonData extends AwsAuthDataSourceSecureJsonData { }
@@ -21,9 +77,133 @@ export interface DynamoDBDataSourceSecureJsonData extends AwsAuthDataSourceSecur
 export const DatetimeFormat = {
   UnixTimestampSeconds: "1",
   UnixTimestampMiniseconds: "2",
+  UnixSeconds: "unix_s",
+  UnixMilliseconds: "unix_ms",
+  UnixMicroseconds: "unix_us",
+  UnixNanoseconds: "unix_ns",
+  YYYYMMDD: "yyyymmdd",
+  Auto: "auto",
   CustomFormat: "custom"
 };
+
+// Formats understood by the backend as they are. Other formats are day.js formats
+export const NamedDatetimeFormats = [
+  DatetimeFormat.UnixTimestampSeconds,
+  DatetimeFormat.UnixTimestampMiniseconds,
+  DatetimeFormat.UnixSeconds,
+  DatetimeFormat.UnixMilliseconds,
+  DatetimeFormat.UnixMicroseconds,
+  DatetimeFormat.UnixNanoseconds,
+  DatetimeFormat.YYYYMMDD,
+  DatetimeFormat.Auto
+];
+export const ListFlattenMode = {
+  JSON: "json",
+  Index: "index"
+};
+
 export interface DatetimeAttribute {
   name: string;
   format: string;
+  fallbacks?: string[];
+  timezone?: string;
+}
+
+export interface CompositeAttribute {
+  name: string;
+  template?: string;
+  pattern?: string;
+}
+
+export interface ExtractedAttribute {
+  name: string;
+  path: string;
+  type?: string;
+  format?: string;
+}
+
+export const BinaryEncoding = {
+  Base64: "base64",
+  Hex: "hex",
+  Text: "utf8",
+  Gzip: "gzip",
+  Zlib: "zlib"
+};
+
+export interface BinaryAttribute {
+  name: string;
+  encoding: string;
+}
+
+export const NumberPrecision = {
+  Auto: "",
+  String: "string",
+  Float: "float",
+  Strict: "strict"
+};
+
+export interface NumberPrecisionAttribute {
+  name: string;
+  precision: string;
+}
+
+export const AttributeType = {
+  String: "string",
+  Number: "number",
+  Int: "int",
+  Float: "float",
+  Bool: "bool",
+  Time: "time",
+  JSON: "json"
+};
+
+export interface TypedAttribute {
+  name: string;
+  type: string;
+}
+
+export interface DefaultDatetimeAttribute extends DatetimeAttribute {
+  table?: string;
+}
+
+export const OutputMode = {
+  Table: "table",
+  Numeric: "numeric",
+  Logs: "logs",
+  Trace: "trace",
+  NodeGraph: "nodegraph"
+};
+
+export const NumericFormat = {
+  Long: "long",
+  Wide: "wide"
+};
+
+export interface TraceMapping {
+  traceId?: string;
+  spanId?: string;
+  parentSpanId?: string;
+  serviceName?: string;
+  operationName?: string;
+  startTime?: string;
+  duration?: string;
+  durationUnit?: string;
+  tags?: string[];
+}
+
+export interface NodeGraphMapping {
+  nodeId?: string;
+  nodeTitle?: string;
+  nodeMainStat?: string;
+  nodeSecondaryStat?: string;
+  edgeSource?: string;
+  edgeTarget?: string;
+  edgePrefix?: string;
+  trimPrefixes?: string[];
+}
+
+export interface EntityDiscriminator {
+  attribute?: string;
+  separator?: string;
+  pattern?: string;
 }